@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/addjam/fsm-processor/processor"
+)
+
+// explainCommand is the first argument that explains the outcome for a claim or dependent rather than processing
+const explainCommand = "explain"
+
+// explain outputs the decisions about a claim or dependent from the trace of a previous run, then exits
+//
+// args - the arguments after the command, flags followed by a claim number, NINO or SEEMIS reference
+func explain(args []string) {
+	flags := flag.NewFlagSet(os.Args[0]+" "+explainCommand, flag.ExitOnError)
+	tracePath := flags.String("trace", "./trace.ndjson", "filepath of the trace written by a run, trace.ndjson in its output folder")
+	textMode := flags.Bool("text", false, "output the explanation as text rather than json")
+	flags.Parse(args)
+
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		RespondWithExplanation(nil, fmt.Errorf("A claim number, NINO or SEEMIS reference to explain is required"), *textMode)
+	}
+
+	trace, err := processor.ReadTrace(*tracePath)
+	if err != nil {
+		RespondWithExplanation(nil, err, *textMode)
+	}
+
+	decisions := trace.Explain(query)
+	if len(decisions) == 0 {
+		err = fmt.Errorf(`No decisions about "%s" in the trace "%s"`, query, *tracePath)
+	}
+
+	RespondWithExplanation(decisions, err, *textMode)
+}
@@ -0,0 +1,258 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry
+type Level int
+
+// Levels from least to most severe, the zero Level is LevelInfo
+const (
+	LevelDebug Level = iota - 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel returns the Level with the name, e.g. "warn"
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, ErrUnknownLevel{name: name}
+}
+
+// MarshalText writes the Level as its name, so it's readable in json and yaml
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText reads a Level from its name
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = level
+	return nil
+}
+
+// Set reads a Level from its name, so a Level can be used as a flag.Value
+func (l *Level) Set(name string) error {
+	return l.UnmarshalText([]byte(name))
+}
+
+// ErrUnknownLevel represents a level name that isn't debug, info, warn or error
+type ErrUnknownLevel struct {
+	name string
+}
+
+func (e ErrUnknownLevel) Error() string {
+	return fmt.Sprintf(`Unknown log level "%s", expected debug, info, warn or error`, e.name)
+}
+
+// Fields are the context of a log entry, e.g. the stage, claim number, file or row
+type Fields map[string]interface{}
+
+// Entry is a line of log output
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  Fields
+}
+
+// MarshalJSON writes the entry as a single object, with its fields alongside the time, level and message
+func (e Entry) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	write := func(key string, value interface{}) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		} else {
+			b.WriteByte('{')
+		}
+		keyData, _ := json.Marshal(key)
+		b.Write(keyData)
+		b.WriteByte(':')
+		b.Write(data)
+		return nil
+	}
+
+	write("time", e.Time)
+	write("level", e.Level)
+	write("msg", e.Message)
+
+	keys := []string{}
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := write(key, e.Fields[key])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// SummaryLimit is the most warnings and errors a Summary keeps the entries of
+const SummaryLimit = 100
+
+// Summary is a bounded summary of the warnings and errors logged
+type Summary struct {
+	Warnings int     `json:"warnings"`
+	Errors   int     `json:"errors"`
+	Entries  []Entry `json:"entries"` // the first warnings and errors logged, up to SummaryLimit
+	Omitted  int     `json:"omitted"` // how many warnings and errors there were after the limit
+}
+
+// sink is where the entries of a Logger, and those derived from it by With, go
+type sink struct {
+	mu      sync.Mutex
+	out     io.Writer
+	level   Level
+	summary Summary
+}
+
+// Logger writes entries at or above its level to a sink as json lines, and keeps a summary of
+// the warnings and errors. It's safe for concurrent use, and a nil Logger discards everything.
+type Logger struct {
+	sink   *sink
+	fields Fields
+}
+
+// New creates a Logger writing entries at or above level to out, which is optional
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{sink: &sink{out: out, level: level, summary: Summary{Entries: []Entry{}}}}
+}
+
+// With returns a Logger that adds the fields to every entry, sharing the sink and summary of l
+func (l *Logger) With(fields Fields) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	merged := Fields{}
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return &Logger{sink: l.sink, fields: merged}
+}
+
+// Debugf logs detail only needed when investigating a run, mirrors fmt.Printf params
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	l.log(LevelDebug, format, a...)
+}
+
+// Infof logs the progress of a run, mirrors fmt.Printf params
+func (l *Logger) Infof(format string, a ...interface{}) {
+	l.log(LevelInfo, format, a...)
+}
+
+// Warnf logs a problem that didn't stop the run but may affect its results, mirrors fmt.Printf params
+func (l *Logger) Warnf(format string, a ...interface{}) {
+	l.log(LevelWarn, format, a...)
+}
+
+// Errorf logs a problem that stopped part of the run, mirrors fmt.Printf params
+func (l *Logger) Errorf(format string, a ...interface{}) {
+	l.log(LevelError, format, a...)
+}
+
+func (l *Logger) log(level Level, format string, a ...interface{}) {
+	if l == nil {
+		return
+	}
+
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(format, a...),
+		Fields:  l.fields,
+	}
+
+	s := l.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if level >= LevelWarn {
+		s.summarise(entry)
+	}
+
+	if s.out != nil && level >= s.level {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			data, _ = json.Marshal(Entry{Time: entry.Time, Level: level, Message: entry.Message})
+		}
+		s.out.Write(append(data, '\n'))
+	}
+}
+
+func (s *sink) summarise(entry Entry) {
+	if entry.Level == LevelWarn {
+		s.summary.Warnings++
+	} else {
+		s.summary.Errors++
+	}
+
+	if len(s.summary.Entries) < SummaryLimit {
+		s.summary.Entries = append(s.summary.Entries, entry)
+	} else {
+		s.summary.Omitted++
+	}
+}
+
+// Summary returns the summary of the warnings and errors logged so far
+func (l *Logger) Summary() Summary {
+	if l == nil {
+		return Summary{Entries: []Entry{}}
+	}
+
+	l.sink.mu.Lock()
+	defer l.sink.mu.Unlock()
+
+	summary := l.sink.summary
+	summary.Entries = make([]Entry, len(l.sink.summary.Entries))
+	copy(summary.Entries, l.sink.summary.Entries)
+	return summary
+}
@@ -0,0 +1,83 @@
+package llog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("Writes entries at or above the level as json lines", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(&out, LevelInfo)
+
+		logger.Debugf("hidden")
+		logger.Infof("%d rows", 3)
+		logger.With(Fields{"stage": "income", "row": 2}).Warnf("bad value")
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines but got %s", out.String())
+		}
+
+		entry := map[string]interface{}{}
+		err := json.Unmarshal([]byte(lines[1]), &entry)
+		if err != nil {
+			t.Fatalf("Expected a json line but got %s", lines[1])
+		}
+		if entry["level"] != "warn" || entry["msg"] != "bad value" || entry["stage"] != "income" || entry["row"] != 2.0 {
+			t.Errorf("Expected the warning with its fields but got %#v", entry)
+		}
+	})
+
+	t.Run("With doesn't change the fields of the original logger", func(t *testing.T) {
+		logger := New(nil, LevelInfo).With(Fields{"stage": "consent"})
+		logger.With(Fields{"stage": "income"}).Warnf("first")
+		logger.Warnf("second")
+
+		entries := logger.Summary().Entries
+		if entries[0].Fields["stage"] != "income" || entries[1].Fields["stage"] != "consent" {
+			t.Errorf("Expected each entry to have its logger's stage but got %#v", entries)
+		}
+	})
+
+	t.Run("Summarises a bounded number of warnings and errors", func(t *testing.T) {
+		logger := New(nil, LevelError)
+
+		for i := 0; i < SummaryLimit; i++ {
+			logger.Warnf("warning %d", i)
+		}
+		logger.Errorf("error")
+		logger.Infof("not summarised")
+
+		summary := logger.Summary()
+		if summary.Warnings != SummaryLimit || summary.Errors != 1 {
+			t.Errorf("Expected %d warnings and 1 error but got %d and %d", SummaryLimit, summary.Warnings, summary.Errors)
+		}
+		if len(summary.Entries) != SummaryLimit || summary.Omitted != 1 {
+			t.Errorf("Expected %d entries with 1 omitted but got %d and %d", SummaryLimit, len(summary.Entries), summary.Omitted)
+		}
+	})
+
+	t.Run("A nil logger discards everything", func(t *testing.T) {
+		var logger *Logger
+		logger.With(Fields{"stage": "income"}).Errorf("discarded")
+
+		if summary := logger.Summary(); summary.Errors != 0 {
+			t.Errorf("Expected an empty summary but got %#v", summary)
+		}
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	if err != nil || level != LevelWarn {
+		t.Errorf("Expected LevelWarn but got %s, %#v", level, err)
+	}
+
+	_, err = ParseLevel("verbose")
+	if _, ok := err.(ErrUnknownLevel); !ok {
+		t.Errorf("Expected ErrUnknownLevel but got %#v", err)
+	}
+}
@@ -1,178 +1,206 @@
 package main
 
 import (
+	"context"
 	"flag"
-
-	"github.com/addjam/fsm-processor/llog"
-
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/addjam/fsm-processor/processor"
 	"github.com/addjam/fsm-processor/spreadsheet"
 )
 
-// InputData represents all options and files received
-type InputData struct {
-	// Debug options
-	debugClaimNumber int
-
-	// Options
-	rolloverMode  bool // when NLC wipes out the data for the previous year and prepares the award for the next school year.
-	awardCG       bool // e.g. might not awarded after about 20th March
-	benefitAmount float32
-	ctcWtcFigure  float32
-	ctcFigure     float32
-	outputFolder  string
-	devMode       bool
-
-	// File paths
-	benefitExtract  spreadsheet.ParserInput
-	dependentsSHBE  spreadsheet.ParserInput
-	universalCredit spreadsheet.ParserInput
-	fsmCgAwards     spreadsheet.ParserInput
-	schoolRoll      spreadsheet.ParserInput
-	consent360      spreadsheet.ParserInput
-	filter          spreadsheet.ParserInput
+// cliOptions are the flags that aren't part of the processor config
+type cliOptions struct {
+	configPath string
+	listSheets string
+	logMode    bool
+	logFile    string
+
+	progressMode bool
+	progressFd   int
+
+	timeout time.Duration
+}
+
+// float32Value is a flag.Value for float32 config options
+type float32Value struct {
+	value *float32
+}
+
+func (f float32Value) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*f.value), 'f', -1, 32)
+}
+
+func (f float32Value) Set(s string) error {
+	value, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return err
+	}
+
+	*f.value = float32(value)
+	return nil
 }
 
 func main() {
-	inputData := parseInputData()
+	if len(os.Args) > 1 && os.Args[1] == explainCommand {
+		explain(os.Args[2:])
+	}
+
+	config, options, err := parseConfig()
+	if err != nil {
+		RespondWith(processor.Result{Config: config}, err)
+	}
 
-	llog.Printf("Rollover? %t\n", inputData.rolloverMode)
+	ctx, cancel := runContext(options.timeout)
+	defer cancel()
 
-	fsmStore := GenerateFsmAwards(inputData)
-	ctrStore := GenerateCtrBasedAwards(inputData, fsmStore)
+	result, err := processor.Run(ctx, config)
+	RespondWith(result, err)
+}
 
-	RespondWith(&fsmStore, &ctrStore, nil)
+// runContext returns the context of a run, cancelled on an interrupt or once the timeout has passed if there is one
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	stopTimeout := func() {}
+	if timeout > 0 {
+		ctx, stopTimeout = context.WithTimeout(ctx, timeout)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		cancel()
+		stopTimeout()
+	}
 }
 
-func parseInputData() InputData {
-	outputFolderPtr := flag.String("output", "./", "path of the folder outputs should be stored in")
-	debugClaimNumberPtr := flag.Int("debugclaim", -1, "claimnumber to output debug logs for")
-	benefitExtractPtr := flag.String("benefitextract", "", "filepath for benefit extract spreadsheet")
-	dependentsSHBEPtr := flag.String("dependents", "", "filepath for dependents SHBE spreadsheet")
-	universalCreditPtr := flag.String("universalcredit", "", "filepath for universal credit spreadsheet")
-	fsmCgAwardsPtr := flag.String("awards", "", "filepath for current awards spreadsheet")
-	schoolRollPtr := flag.String("schoolroll", "", "filepath for school roll spreadsheet")
-	consent360Ptr := flag.String("consent", "", "filepath for consent spreadsheet")
-	filterPtr := flag.String("filter", "", "filepath for filter spreadsheet")
-	rolloverModePtr := flag.Bool("rollover", false, "rollover mode")
-	awardCGPtr := flag.Bool("awardcg", true, "if we should award CG")
-	developmentModePtr := flag.Bool("dev", false, "development mode, use private-data")
-	logModePtr := flag.Bool("log", false, "log output to stdout (breaks json output)")
-	benefitAmountPtr := flag.Float64("benefitamount", 610.0, "benefit amount")           // default £610
-	ctcWtcFigure := flag.Float64("ctcwtcfigure", 6420.0, "ctc/wtc annual income figure") // default £6420
-	ctcFigure := flag.Float64("ctcfigure", 16105.0, "ctc annual income figure")          // default £16105
-	flag.Parse()
-
-	path := func(inputPath, devModePath string) string {
-		var outputPath string
-		if inputPath != "" {
-			outputPath = inputPath
-		} else if *developmentModePtr {
-			outputPath = devModePath
+// parseConfig reads the config file if there is one, then overrides it with any flags that were set
+func parseConfig() (processor.Config, cliOptions, error) {
+	config := processor.DefaultConfig()
+	options := cliOptions{progressFd: -1}
+
+	flags := newFlagSet(&config, &options)
+	flags.Parse(os.Args[1:])
+
+	if options.listSheets != "" {
+		sheets, err := spreadsheet.ListSheets(spreadsheet.ParserInput{Path: options.listSheets})
+		RespondWithSheets(sheets, err)
+	}
+
+	if options.configPath != "" {
+		var err error
+		config, err = processor.LoadConfig(options.configPath)
+		if err != nil {
+			return config, options, err
 		}
 
-		if outputPath == "" {
-			RespondWith(nil, nil, ErrInvalidInputPath{filePath: inputPath})
+		// Parse again so flags take precedence over the file
+		newFlagSet(&config, &options).Parse(os.Args[1:])
+	}
+
+	if config.DevMode {
+		useDevModePaths(&config)
+	}
+
+	logs := []io.Writer{}
+	if options.logMode {
+		logs = append(logs, os.Stderr)
+	}
+	if options.logFile != "" {
+		file, err := os.Create(options.logFile)
+		if err != nil {
+			return config, options, err
 		}
+		logs = append(logs, file)
+	}
+	if len(logs) > 0 {
+		config.Log = io.MultiWriter(logs...)
+	}
 
-		return outputPath
-	}
-
-	llog.PrintToStdout = *logModePtr
-
-	return InputData{
-		debugClaimNumber: *debugClaimNumberPtr,
-
-		rolloverMode:  *rolloverModePtr,
-		awardCG:       *awardCGPtr,
-		benefitAmount: float32(*benefitAmountPtr),
-		ctcWtcFigure:  float32(*ctcWtcFigure),
-		ctcFigure:     float32(*ctcFigure),
-		outputFolder:  *outputFolderPtr,
-		devMode:       *developmentModePtr,
-
-		benefitExtract: spreadsheet.ParserInput{
-			Path:       path(*benefitExtractPtr, "./private-data/Benefit Extract.txt"),
-			HasHeaders: true,
-			RequiredHeaders: []string{
-				// Extracted in consent check
-				"Claim Number",
-				"Clmt First Forename",
-				"Clmt Surname",
-
-				// Tax credit step one
-				"Clmt Personal Pension",
-				"Clmt State Retirement Pension (incl SERP's graduated pension etc)",
-				"Ptnr Personal Pension",
-				"Ptnr State Retirement Pension (incl SERP's graduated pension etc)",
-				"Clmt Occupational Pension",
-				"Ptnr Occupational Pension",
-
-				// Tax credit step two
-				"Clmt AIF",
-				"Clmt Employment (gross)",
-				"Clmt Self-employment (gross)",
-				"Clmt Student Grant/Loan",
-				"Clmt Sub-tenants",
-				"Clmt Boarders",
-				"Clmt Government Training",
-				"Clmt Statutory Sick Pay",
-				"Clmt Widowed Parent's Allowance",
-				"Clmt Apprenticeship",
-				"Clmt Statutory Sick Pay",
-				"Other weekly Income including In-Work Credit",
-				"Ptnr AIF",
-				"Ptnr Employment (gross)",
-				"Ptnr Self-employment (gross)",
-				"Ptnr Student Grant/Loan",
-				"Ptnr Sub-tenants",
-				"Ptnr Boarders",
-				"Ptnr Training for Work/Community Action",
-				"Ptnr New Deal 50+ Employment Credit",
-				"Ptnr Government Training",
-				"Ptnr Carer's Allowance",
-				"Ptnr Statutory Sick Pay",
-				"Ptnr Widowed Parent's Allowance",
-				"Ptnr Apprenticeship",
-				"Other weekly Income including In-Work Credit",
-				"Clmt Savings Credit",
-				"Ptnr Savings Credit",
-				"Clmt Widows Benefit",
-				"Ptnr Widows Benefit",
-			},
-		},
-		dependentsSHBE: spreadsheet.ParserInput{
-			Path:       path(*dependentsSHBEPtr, "./private-data/dependants SHBE.xlsx"),
-			HasHeaders: true,
-		},
-		universalCredit: spreadsheet.ParserInput{
-			Path:       path(*universalCreditPtr, "./private-data/hb-uc.d.txt"),
-			HasHeaders: false,
-			Format:     spreadsheet.Ssv,
-		},
-		fsmCgAwards: spreadsheet.ParserInput{
-			Path:       path(*fsmCgAwardsPtr, "./private-data/Current Year Awards.xlsx"),
-			HasHeaders: true,
-		},
-		schoolRoll: spreadsheet.ParserInput{
-			Path:       path(*schoolRollPtr, "./private-data/School Roll.xlsx"),
-			HasHeaders: true,
-		},
-		consent360: spreadsheet.ParserInput{
-			Path:       path(*consent360Ptr, "./private-data/Consent Report.xls"),
-			HasHeaders: true,
-			RequiredHeaders: []string{
-				"DocDesc",
-				"DocDate",
-				"CLAIMREFERENCE",
-			},
-		},
-		filter: spreadsheet.ParserInput{
-			Path:       path(*filterPtr, "./private-data/Filter File-Test.xlsx"),
-			HasHeaders: true,
-			RequiredHeaders: []string{
-				"claim ref",
-				"seemis ID",
-			},
-		},
+	progress := []io.Writer{}
+	if options.progressMode {
+		progress = append(progress, os.Stderr)
 	}
+	if options.progressFd >= 0 {
+		progress = append(progress, os.NewFile(uintptr(options.progressFd), "progress"))
+	}
+	if len(progress) > 0 {
+		config.Progress = io.MultiWriter(progress...)
+	}
+
+	return config, options, nil
+}
+
+// newFlagSet creates the command line flags, setting the options of the config and CLI.
+// Defaults are the values already in the config.
+func newFlagSet(config *processor.Config, options *cliOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	flags.StringVar(&options.configPath, "config", "", "filepath of a YAML or JSON config file, flags override its options")
+	flags.StringVar(&config.OutputFolder, "output", config.OutputFolder, "path of the folder outputs should be stored in")
+	flags.StringVar(&config.OutputFormat, "outputformat", config.OutputFormat, "format of the outputs, csv files or a single xlsx workbook")
+	flags.IntVar(&config.DebugClaimNumber, "debugclaim", config.DebugClaimNumber, "claimnumber to output debug logs for")
+	flags.StringVar(&config.BenefitExtract, "benefitextract", config.BenefitExtract, "filepath for benefit extract spreadsheet")
+	flags.StringVar(&config.DependentsSHBE, "dependents", config.DependentsSHBE, "filepath for dependents SHBE spreadsheet")
+	flags.StringVar(&config.UniversalCredit, "universalcredit", config.UniversalCredit, "filepath for universal credit spreadsheet")
+	flags.StringVar(&config.Awards, "awards", config.Awards, "filepath for current awards spreadsheet")
+	flags.StringVar(&config.SchoolRoll, "schoolroll", config.SchoolRoll, "filepath for school roll spreadsheet")
+	flags.StringVar(&config.Consent, "consent", config.Consent, "filepath for consent spreadsheet")
+	flags.StringVar(&config.Filter, "filter", config.Filter, "filepath for filter spreadsheet")
+	flags.StringVar(&config.DependentsSheet, "dependentssheet", config.DependentsSheet, "name of the sheet to use in the dependents SHBE spreadsheet")
+	flags.StringVar(&config.AwardsSheet, "awardssheet", config.AwardsSheet, "name of the sheet to use in the current awards spreadsheet")
+	flags.StringVar(&config.SchoolRollSheet, "schoolrollsheet", config.SchoolRollSheet, "name of the sheet to use in the school roll spreadsheet")
+	flags.StringVar(&config.ConsentSheet, "consentsheet", config.ConsentSheet, "name of the sheet to use in the consent spreadsheet, defaults to the first with the expected headers")
+	flags.StringVar(&config.FilterSheet, "filtersheet", config.FilterSheet, "name of the sheet to use in the filter spreadsheet, defaults to the first with the expected headers")
+	flags.StringVar(&options.listSheets, "listsheets", "", "filepath of a workbook to list the sheet names of, no processing is done")
+	flags.BoolVar(&config.ValidateOnly, "validate", config.ValidateOnly, "check the inputs against their schemas, no processing is done")
+	flags.BoolVar(&config.RolloverMode, "rollover", config.RolloverMode, "rollover mode")
+	flags.BoolVar(&config.AwardCG, "awardcg", config.AwardCG, "if we should award CG")
+	flags.BoolVar(&config.DevMode, "dev", config.DevMode, "development mode, use private-data")
+	flags.BoolVar(&config.Lenient, "lenient", config.Lenient, "skip malformed rows and exclude claims with invalid values rather than failing, they're listed in the output")
+	flags.BoolVar(&options.logMode, "log", false, "write the log to stderr as json lines")
+	flags.StringVar(&options.logFile, "logfile", "", "filepath to write the log to as json lines")
+	flags.Var(&config.LogLevel, "loglevel", "least severe `level` of log entries written, debug, info, warn or error")
+	flags.DurationVar(&options.timeout, "timeout", options.timeout, "stop the run after this `duration`, e.g. 30m, its outputs are deleted")
+	flags.BoolVar(&options.progressMode, "progress", false, "write progress events to stderr as json lines")
+	flags.IntVar(&options.progressFd, "progressfd", options.progressFd, "file `descriptor` to write progress events to as json lines, e.g. 3")
+	flags.Var(float32Value{&config.BenefitAmount}, "benefitamount", "benefit `amount`")
+	flags.Var(float32Value{&config.CtcWtcFigure}, "ctcwtcfigure", "ctc/wtc annual income `figure`")
+	flags.Var(float32Value{&config.CtcFigure}, "ctcfigure", "ctc annual income `figure`")
+
+	return flags
+}
+
+// useDevModePaths uses the files in the private-data folder for inputs that haven't been given
+func useDevModePaths(config *processor.Config) {
+	path := func(inputPath *string, devModePath string) {
+		if *inputPath == "" {
+			*inputPath = devModePath
+		}
+	}
+
+	path(&config.BenefitExtract, "./private-data/Benefit Extract.txt")
+	path(&config.DependentsSHBE, "./private-data/dependants SHBE.xlsx")
+	path(&config.UniversalCredit, "./private-data/hb-uc.d.txt")
+	path(&config.Awards, "./private-data/Current Year Awards.xlsx")
+	path(&config.SchoolRoll, "./private-data/School Roll.xlsx")
+	path(&config.Consent, "./private-data/Consent Report.xls")
+	path(&config.Filter, "./private-data/Filter File-Test.xlsx")
 }
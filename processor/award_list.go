@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// awardListHeaders are the columns of an award list
+var awardListHeaders = []string{
+	"Record no", "SEEMIS reference",
+
+	// Benefit Extract Columns
+	"Claim Number", "NINO", "Clmt Title", "Clmt First Forename", "Clmt Surname",
+	"Ptnr NINO", "Ptnr First Forename", "Ptnr Surname",
+	"Address1", "PostCode", "Address2", "Address3", "Address4", "Address5",
+
+	// Consent360
+	"Consent",
+
+	// School Roll
+	"Forename", "Surname", "Date of Birth", "Pupil's property",
+	"Pupil's street", "Pupil's town", "School Name", "School Name 2",
+	"Year/Stage",
+
+	// New
+	"Name match", "Address match",
+
+	// FSM&CG Awards
+	"NI Number", "Payrun Date",
+
+	// New
+	"CG Qualifier",
+
+	// FSM&CG Awards
+	"FSM Approved",
+
+	// New
+	"FSM Qualifier", "Next step", "check attendance",
+}
+
+// WriteAwardList looks at the AwardDependents and writes an award list sheet.
+// Records are numbered from firstRecord so numbers are unique across award lists.
+func WriteAwardList(writer spreadsheet.Writer, inputData InputData, store PeopleStore, sheetName string, firstRecord int) error {
+	inputData.log.Infof("Writing awards list for %d dependents to %s", len(store.AwardDependents), sheetName)
+
+	err := writer.AddSheet(sheetName, awardListHeaders)
+	if err != nil {
+		return err
+	}
+
+	for i, d := range store.AwardDependents {
+		err = writer.Write(buildLine(inputData, d, firstRecord+i))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func buildLine(inputData InputData, d Dependent, record int) []spreadsheet.Cell {
+	line := []spreadsheet.Cell{
+		spreadsheet.NumberCell(float64(record)),
+
+		spreadsheet.TextCell(d.Seemis),
+
+		// Benefit Extract Columns
+		spreadsheet.TextCell(fmt.Sprintf("%d", d.Person.ClaimNumber)),
+	}
+
+	benefitColumns := []string{"NINO", "Clmt Title", "Clmt First Forename", "Clmt Surname",
+		"Ptnr NINO", "Ptnr First Forename", "Ptnr Surname",
+		"Address1", "PostCode", "Address2", "Address3", "Address4", "Address5"}
+	benefitRow := d.Person.BenefitExtractRow
+	for _, colName := range benefitColumns {
+		value := spreadsheet.ColByName(benefitRow, colName)
+		line = append(line, spreadsheet.TextCell(value))
+	}
+
+	// Consent360
+	line = append(line, spreadsheet.TextCell(d.Person.ConsentStr()))
+
+	// School Roll
+
+	schoolRollRow := d.SchoolRollRow
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Forename")))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Surname")))
+	line = append(line, spreadsheet.DateCell(d.Dob, "02-01-2006"))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Pupil's property")))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Pupil's street")))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Pupil's town")))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "School Name")))
+	line = append(line, spreadsheet.TextCell(""))
+	line = append(line, spreadsheet.TextCell(spreadsheet.ColByName(schoolRollRow, "Year/Stage")))
+
+	// Name match and address match
+	line = append(line, scoreCell(d.NameMatchScore))
+	line = append(line, scoreCell(d.AddressMatchScore))
+
+	// FSM&CG Awards
+	line = append(line, spreadsheet.TextCell(d.AwardsNINumber))
+	line = append(line, spreadsheet.TextCell(d.AwardsPayrunDate))
+
+	// New
+	if d.NewCG {
+		line = append(line, spreadsheet.TextCell("HB-LCTR IN PAYMENT"))
+	} else {
+		line = append(line, spreadsheet.TextCell(""))
+	}
+
+	// FSM&CG Awards
+	line = append(line, spreadsheet.TextCell(d.AwardsFsmApproved))
+
+	// New
+	line = append(line, spreadsheet.TextCell(d.Person.QualiferType))
+
+	line = append(line, spreadsheet.TextCell(LetterForDependent(d, inputData.rolloverMode).String()))
+
+	if d.IsAtLeast16(inputData.rolloverMode) {
+		line = append(line, spreadsheet.TextCell("Yes"))
+	} else {
+		line = append(line, spreadsheet.TextCell("No"))
+	}
+
+	return line
+}
+
+// scoreCell creates a number cell for a match score, with the same text as previous CSV outputs
+func scoreCell(score float64) spreadsheet.Cell {
+	cell := spreadsheet.NumberCell(score)
+	cell.Text = fmt.Sprintf("%f", score)
+	return cell
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the version of the config file format read by LoadConfig
+const ConfigVersion = 1
+
+// LoadConfig reads a YAML or JSON config file, depending on its extension. Options the
+// file doesn't set keep their values from DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	config.Version = 0
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&config)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&config)
+	}
+	if err != nil {
+		return config, ErrInvalidConfig{filePath: path, reason: err.Error()}
+	}
+
+	if config.Version != ConfigVersion {
+		return config, ErrUnsupportedConfigVersion{filePath: path, version: config.Version}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/addjam/fsm-processor/llog"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("Applies the options in a YAML file", func(t *testing.T) {
+		config, err := LoadConfig("./testdata/config.yaml")
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+
+		if config.BenefitExtract != "./testdata/Benefit Extract_06_09_19.txt" {
+			t.Errorf("Expected the benefit extract path but got %s", config.BenefitExtract)
+		}
+		if config.AwardCG {
+			t.Errorf("Expected AwardCG to be false")
+		}
+		if config.PensionAllowance != 250 {
+			t.Errorf("Expected a pension allowance of 250 but got %f", config.PensionAllowance)
+		}
+		if !reflect.DeepEqual(config.PassportedIndicators, []string{"Income Support"}) {
+			t.Errorf("Expected only Income Support to be passported but got %v", config.PassportedIndicators)
+		}
+		if config.SchoolMatchThreshold != 0.9 {
+			t.Errorf("Expected a school match threshold of 0.9 but got %f", config.SchoolMatchThreshold)
+		}
+		if config.LogLevel != llog.LevelWarn {
+			t.Errorf("Expected the warn log level but got %s", config.LogLevel)
+		}
+		if config.DataIssues[IssueInvalidDob] != ExcludeClaim {
+			t.Errorf("Expected invalid dobs to exclude the claim but got %v", config.DataIssues)
+		}
+		if !reflect.DeepEqual(config.ColumnAliases["consent"]["Claim Number"], []string{"Claim No"}) {
+			t.Errorf("Expected the consent column aliases but got %v", config.ColumnAliases["consent"])
+		}
+		if !reflect.DeepEqual(config.CtrStageNames, []string{StageCtr, StageHousehold, StageCGEligible}) {
+			t.Errorf("Expected the CTR stages in the file but got %v", config.CtrStageNames)
+		}
+	})
+
+	t.Run("Keeps the defaults of options the file doesn't set", func(t *testing.T) {
+		config, err := LoadConfig("./testdata/config.json")
+		if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+
+		defaults := DefaultConfig()
+		if config.CtcFigure != 17000 || config.OutputFormat != xlsxOutput {
+			t.Errorf("Expected the options in the file but got %f and %s", config.CtcFigure, config.OutputFormat)
+		}
+		if config.CtcWtcFigure != defaults.CtcWtcFigure {
+			t.Errorf("Expected the default ctc/wtc figure but got %f", config.CtcWtcFigure)
+		}
+		if config.AwardMatchThreshold != defaults.AwardMatchThreshold {
+			t.Errorf("Expected the default award match threshold but got %f", config.AwardMatchThreshold)
+		}
+		if len(config.ColumnAliases["benefit_extract"]) == 0 {
+			t.Errorf("Expected the default benefit extract aliases")
+		}
+	})
+
+	t.Run("Rejects an unsupported version", func(t *testing.T) {
+		_, err := LoadConfig("./testdata/config_version.yaml")
+
+		if _, ok := err.(ErrUnsupportedConfigVersion); !ok {
+			t.Fatalf("Expected ErrUnsupportedConfigVersion but got %#v", err)
+		}
+	})
+
+	t.Run("Rejects unknown options", func(t *testing.T) {
+		_, err := LoadConfig("./testdata/config_unknown.yaml")
+
+		if _, ok := err.(ErrInvalidConfig); !ok {
+			t.Fatalf("Expected ErrInvalidConfig but got %#v", err)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// AddPeopleWithConsent parses which people have given consent to check entitlement data
+// and adds them directly to the PeopleStore
+// Data sources: Consent 360 & Benefit Extract
+func AddPeopleWithConsent(inputData InputData, peopleStore *PeopleStore) error {
+	consentDescByClaimNumber, err := extractConsentData(inputData)
+
+	if err != nil {
+		return err
+	}
+
+	// The first issue to stop the run, which stops the remaining rows being checked
+	var valueErr error
+
+	// Parse benefits extract
+	numPeople := 0
+	err = spreadsheet.EachRow(inputData.benefitExtract, func(row spreadsheet.Row) {
+		if valueErr != nil {
+			return
+		}
+
+		claimNumStr := spreadsheet.ColByName(row, "Claim Number")
+		if strings.TrimSpace(claimNumStr) == "" {
+			inputData.rowIssue(row, IssueMissingClaimNumber, "Claim Number", "No claim number in the benefit extract")
+			return
+		}
+
+		claimNumber, err := parseClaimNumber(row, "Claim Number", claimNumStr)
+		if err != nil {
+			valueErr = inputData.claimIssue(row, IssueInvalidClaimNumber, 0, err)
+			return
+		}
+
+		desc := consentDescByClaimNumber[claimNumber]
+		hasPermission := desc != inputData.consentRemoved && desc != ""
+		numPeople += 1
+
+		claim := Person{ClaimNumber: claimNumber, Nino: spreadsheet.ColByName(row, "NINO")}
+		evidence := Evidence{"consent description": desc, "benefit extract row": row.Location().String()}
+		if desc == "" {
+			evidence["consent description"] = "none in the consent report"
+		}
+
+		if !hasPermission {
+			inputData.tracePerson(claim, "no consent", evidence)
+			return
+		}
+
+		inputData.tracePerson(claim, "consent given", evidence)
+
+		person, err := NewPersonFromBenefitExtract(row)
+
+		if err != nil {
+			inputData.log.With(rowFields(row)).Warnf("Can't create person from the benefit extract: %s", err)
+			return
+		}
+
+		person.ConsentDesc = desc
+		person.ConsentRemoved = desc == inputData.consentRemoved
+		peopleStore.Add(person)
+	})
+
+	inputData.log.Infof("%d rows checked for consent", numPeople)
+	if err == nil {
+		err = valueErr
+	}
+	return err
+}
+
+func extractConsentData(inputData InputData) (map[int]string, error) {
+	consentData := make(map[int]string)
+
+	err := spreadsheet.EachRow(inputData.consent360, func(row spreadsheet.Row) {
+		column := columnName(row, 2)
+		if strings.TrimSpace(row.Col(2)) == "" {
+			inputData.rowIssue(row, IssueMissingClaimNumber, column, "No claim number in the consent report")
+			return
+		}
+
+		// consent spreadsheet has claim numbers beginning with "TEMP" followed by 6 digits
+		// benefit extract just seems to be numbers. Consent spreadsheet can also have e.g. 000123 but
+		// benefit extract seems to present this as 123
+		claimNum, err := parseClaimNumber(row, column, strings.Replace(row.Col(2), "TEMP", "", 1))
+		if err != nil {
+			// The consent can't be given to a claim it can't be matched to
+			inputData.rowIssue(row, IssueInvalidClaimNumber, column, err.Error())
+			return
+		}
+
+		consentDesc := row.Col(0)
+		consentData[claimNum] = consentDesc
+	})
+
+	return consentData, err
+}
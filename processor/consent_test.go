@@ -1,4 +1,4 @@
-package main
+package processor
 
 import (
 	"testing"
@@ -15,12 +15,16 @@ func TestExtractPeopleWithConsent(t *testing.T) {
 		rolloverMode:  false,
 		benefitAmount: 610, // £610
 
+		consentRemoved: "FSM&CG Consent Removed",
+
 		benefitExtract:  spreadsheet.ParserInput{Path: "./testdata/Benefit Extract_06_09_19.txt", HasHeaders: true},
 		dependentsSHBE:  spreadsheet.ParserInput{Path: "./testdata/dependants SHBE_06-09-19-2.xlsx"},
 		universalCredit: spreadsheet.ParserInput{Path: "./testdata/hb-uc.d-06-09-19.txt"},
 		fsmCgAwards:     spreadsheet.ParserInput{Path: "./testdata/FSM&CGawards_06-09-19.xlsx"},
 		schoolRoll:      spreadsheet.ParserInput{Path: "./testdata/School Roll Pupil Data_06-09-19-2.xlsx"},
 		consent360:      spreadsheet.ParserInput{Path: "./testdata/Consent Report W360.xls"},
+
+		trace: &Trace{},
 	}
 
 	t.Run("finds the correct matches", func(t *testing.T) {
@@ -30,4 +34,20 @@ func TestExtractPeopleWithConsent(t *testing.T) {
 			t.Errorf("Expected 3 people in store, got %d", len(store.People))
 		}
 	})
+
+	t.Run("traces the consent of every claim", func(t *testing.T) {
+		given := 0
+		for _, decision := range inputData.trace.Decisions() {
+			if decision.Outcome == "consent given" {
+				given++
+			}
+			if decision.Evidence["consent description"] == "" {
+				t.Errorf("Expected the consent description as evidence but got %#v", decision)
+			}
+		}
+
+		if given != len(store.People) {
+			t.Errorf("Expected a decision for each of the %d people with consent, got %d", len(store.People), given)
+		}
+	})
 }
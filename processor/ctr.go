@@ -0,0 +1,92 @@
+package processor
+
+import "github.com/addjam/fsm-processor/llog"
+
+// CtrStages returns the stages of the CTR algorithm, combining spreadsheet data and the FSM award
+// list to determine who can get clothing grant based on CTR, and who to report to education
+func CtrStages() []Stage {
+	return []Stage{
+		NewStage(StageCtr, func(inputData InputData, store *PeopleStore) error {
+			return AddPeopleWithCtr(inputData, store)
+		}),
+		NewStage(StageHousehold, householdStage),
+		NewStage(StageCGEligible, func(inputData InputData, store *PeopleStore) error {
+			// Mark everyone as CG eligible
+			for i, p := range store.People {
+				for j, d := range p.Dependents {
+					d.NewCG = inputData.awardCG
+					p.Dependents[j] = d
+				}
+				store.People[i] = p
+			}
+			return nil
+		}),
+		NewStage(StageSchoolMatch, schoolMatchStage),
+		NewStage(StageExistingGrants, existingGrantsStage),
+		NewStage(StageNotReceivingCG, func(inputData InputData, store *PeopleStore) error {
+			filtered := filterNotReceivingCG(store.AwardDependents)
+			inputData.traceFilter(store.AwardDependents, filtered, "not receiving CG", "already receiving CG", entitlementEvidence)
+
+			store.AwardDependents = filtered
+			return nil
+		}),
+		NewStage(StageExclusionList, exclusionListStage),
+		NewStage(StageFsmAwards, func(inputData InputData, store *PeopleStore) error {
+			filtered := filterDependents(store.AwardDependents, inputData.fsmAwards)
+			inputData.traceFilter(store.AwardDependents, filtered, "not in the FSM award list", "in the FSM award list", nil)
+
+			store.AwardDependents = filtered
+			return nil
+		}),
+		NewStage(StageMinimumP1, minimumP1Stage),
+	}
+}
+
+// GenerateCtrBasedAwards runs the stages of the CTR algorithm, leaving out dependents in the FSM award
+// list. Returns the store and the counts before and after each stage, or the store as it was when an
+// error stopped the algorithm.
+func GenerateCtrBasedAwards(inputData InputData, fsmStore PeopleStore) (PeopleStore, []StageStats, error) {
+	inputData.fsmAwards = fsmStore.AwardDependents
+	inputData.algorithm = PhaseCtr
+	inputData.log = inputData.log.With(llog.Fields{"algorithm": PhaseCtr})
+
+	store := PeopleStore{}
+	stats, err := runStages(inputData, inputData.ctrStages, &store)
+	if err == nil {
+		inputData.traceLetters(store.AwardDependents)
+	}
+	return store, stats, err
+}
+
+func filterNotReceivingCG(dependents []Dependent) []Dependent {
+	filtered := []Dependent{}
+
+	for _, d := range dependents {
+		if !d.ExistingCG {
+			filtered = append(filtered, d)
+		}
+	}
+
+	return filtered
+}
+
+func filterDependents(dependents []Dependent, filterList []Dependent) []Dependent {
+	filtered := []Dependent{}
+
+	for _, d := range dependents {
+		isFiltered := false
+
+		for _, filtered := range filterList {
+			if d.Seemis == filtered.Seemis {
+				isFiltered = true
+				break
+			}
+		}
+
+		if !isFiltered {
+			filtered = append(filtered, d)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,245 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// Severity is how much a data quality issue affected a run
+type Severity string
+
+// Severities of data quality issues, from least to most severe
+const (
+	// SeverityWarning is a row skipped or a value replaced while reading an input, the results may be affected
+	SeverityWarning Severity = "warning"
+
+	// SeverityError is a claim left out of the run because of the issue
+	SeverityError Severity = "error"
+
+	// SeverityFatal is an issue that stopped the run
+	SeverityFatal Severity = "fatal"
+)
+
+// Kinds of data quality issues
+const (
+	IssueMalformedRow       = "malformed row"
+	IssueInvalidValue       = "invalid value"
+	IssueMissingClaimNumber = "missing claim number"
+	IssueInvalidClaimNumber = "invalid claim number"
+	IssueInvalidAge         = "invalid age"
+	IssueInvalidDob         = "invalid dob"
+	IssueMissingIncome      = "missing income"
+)
+
+// claimIssueKinds are the kinds of issues affecting a claim, which an IssuePolicy can be set for
+var claimIssueKinds = []string{IssueInvalidClaimNumber, IssueInvalidAge, IssueInvalidDob, IssueMissingIncome}
+
+// isClaimIssueKind returns true if the kind of issue affects a claim
+func isClaimIssueKind(kind string) bool {
+	for _, k := range claimIssueKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuePolicy decides what happens when a claim has a data quality issue
+type IssuePolicy string
+
+const (
+	// AbortRun stops the run at the issue
+	AbortRun IssuePolicy = "abort"
+
+	// ExcludeClaim leaves the claim out of the run and carries on, or skips the row if its claim isn't known
+	ExcludeClaim IssuePolicy = "exclude"
+)
+
+// UnmarshalText reads an IssuePolicy, returning an error if it isn't abort or exclude
+func (p *IssuePolicy) UnmarshalText(text []byte) error {
+	policy := IssuePolicy(strings.ToLower(strings.TrimSpace(string(text))))
+	if policy != AbortRun && policy != ExcludeClaim {
+		return ErrInvalidIssuePolicy{policy: string(text)}
+	}
+
+	*p = policy
+	return nil
+}
+
+// DataIssue is a problem with the data in an input, and what was done about it
+type DataIssue struct {
+	Severity    Severity             `json:"severity"`
+	Kind        string               `json:"kind"`
+	Location    spreadsheet.Location `json:"location"`
+	Column      string               `json:"column,omitempty"`
+	Value       string               `json:"value,omitempty"`
+	ClaimNumber int                  `json:"claim_number,omitempty"` // 0 if the claim isn't known
+	Action      string               `json:"action"`
+	Reason      string               `json:"reason"`
+}
+
+// dataQuality collects the data quality issues found by the stages of a run. It's shared by
+// copies of the run's InputData, is safe for concurrent use, and a nil dataQuality records nothing.
+type dataQuality struct {
+	mu            sync.Mutex
+	policies      map[string]IssuePolicy
+	defaultPolicy IssuePolicy
+	issues        []DataIssue
+	seen          map[DataIssue]bool
+}
+
+// newDataQuality creates a dataQuality using the policies by kind of issue, and defaultPolicy for other kinds
+func newDataQuality(policies map[string]IssuePolicy, defaultPolicy IssuePolicy) *dataQuality {
+	return &dataQuality{policies: policies, defaultPolicy: defaultPolicy}
+}
+
+// policy returns the policy for the kind of issue
+func (q *dataQuality) policy(kind string) IssuePolicy {
+	if q == nil {
+		return ExcludeClaim
+	}
+
+	if policy, ok := q.policies[kind]; ok {
+		return policy
+	}
+	return q.defaultPolicy
+}
+
+// add records an issue, returning false if it was already recorded, e.g. when an input is read by more than one stage
+func (q *dataQuality) add(issue DataIssue) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.seen[issue] {
+		return false
+	}
+	if q.seen == nil {
+		q.seen = make(map[DataIssue]bool)
+	}
+	q.seen[issue] = true
+
+	q.issues = append(q.issues, issue)
+	return true
+}
+
+// Issues returns the issues recorded so far, in the order they were found
+func (q *dataQuality) Issues() []DataIssue {
+	if q == nil {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	issues := make([]DataIssue, len(q.issues))
+	copy(issues, q.issues)
+	return issues
+}
+
+// dataIssues returns all the data quality issues of a run, those found reading the inputs then those found by its stages
+func dataIssues(inputData InputData) []DataIssue {
+	issues := []DataIssue{}
+
+	for _, issue := range inputData.report.Issues() {
+		kind := IssueInvalidValue
+		if issue.Column == "" {
+			kind = IssueMalformedRow
+		}
+
+		issues = append(issues, DataIssue{
+			Severity: SeverityWarning,
+			Kind:     kind,
+			Location: issue.Location,
+			Column:   issue.Column,
+			Value:    issue.Value,
+			Action:   issue.Action,
+			Reason:   issue.Reason,
+		})
+	}
+
+	return append(issues, inputData.quality.Issues()...)
+}
+
+// claimIssue records a value in the row of a claim that can't be used, deciding what happens by the policy
+// for the kind of issue. It returns err if the run should stop, otherwise nil and the claim should be excluded.
+func (i InputData) claimIssue(row spreadsheet.Row, kind string, claimNumber int, err error) error {
+	policy := i.quality.policy(kind)
+	issue := DataIssue{
+		Severity:    SeverityError,
+		Kind:        kind,
+		Location:    row.Location(),
+		ClaimNumber: claimNumber,
+		Action:      "excluded claim",
+		Reason:      err.Error(),
+	}
+	if invalid, ok := err.(ErrInvalidValue); ok {
+		issue.Column = invalid.column
+		issue.Value = invalid.value
+	}
+
+	if policy == AbortRun {
+		issue.Severity = SeverityFatal
+		issue.Action = "stopped run"
+	} else if claimNumber == 0 {
+		issue.Action = "skipped row"
+	}
+
+	if policy == AbortRun {
+		// the error is logged when the run stops
+		i.quality.add(issue)
+		return err
+	}
+
+	if i.quality.add(issue) {
+		log := i.log.With(rowFields(row))
+		if claimNumber != 0 {
+			log = log.With(claimFields(claimNumber))
+		}
+		log.Warnf("%s, %s", err, issue.Action)
+	}
+	return nil
+}
+
+// rowIssue records a row that was skipped because of the kind of issue, which doesn't affect a known claim
+func (i InputData) rowIssue(row spreadsheet.Row, kind string, column string, reason string) {
+	issue := DataIssue{
+		Severity: SeverityWarning,
+		Kind:     kind,
+		Location: row.Location(),
+		Column:   column,
+		Value:    spreadsheet.ColByName(row, column),
+		Action:   "skipped row",
+		Reason:   reason,
+	}
+
+	if i.quality.add(issue) {
+		i.log.With(rowFields(row)).Warnf("%s, skipped row", reason)
+	}
+}
+
+// parseClaimNumber parses the claim number in the column of the row
+func parseClaimNumber(row spreadsheet.Row, column string, value string) (int, error) {
+	claimNumber, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, ErrInvalidValue{location: row.Location(), name: "claim number", column: column, value: value}
+	}
+
+	return claimNumber, nil
+}
+
+// columnName returns the header of the column at index in the row, or its position if it has no headers
+func columnName(row spreadsheet.Row, index int) string {
+	headers := row.Headers()
+	if index < len(headers) && headers[index] != "" {
+		return headers[index]
+	}
+
+	return "column " + strconv.Itoa(index+1)
+}
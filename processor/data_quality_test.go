@@ -0,0 +1,236 @@
+package processor
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+func TestHouseholdDataQuality(t *testing.T) {
+	store := PeopleStore{People: []Person{{ClaimNumber: 1}, {ClaimNumber: 2}}}
+
+	newHouseholdInputData := func(policy IssuePolicy) InputData {
+		return InputData{
+			dependentsSHBE: spreadsheet.ParserInput{Path: "./testdata/dependants SHBE invalid.csv", HasHeaders: true},
+			quality:        newDataQuality(nil, policy),
+			trace:          &Trace{},
+		}
+	}
+
+	t.Run("Excludes the claim with an invalid dob", func(t *testing.T) {
+		inputData := newHouseholdInputData(ExcludeClaim)
+
+		people, err := PeopleInHouseholdsWithChildren(inputData, store)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(people) != 1 || people[0].ClaimNumber != 2 {
+			t.Errorf("Expected only claim 2 but got %#v", people)
+		}
+
+		issues := inputData.quality.Issues()
+		if len(issues) != 1 {
+			t.Fatalf("Expected 1 issue but got %#v", issues)
+		}
+		issue := issues[0]
+		if issue.Kind != IssueInvalidDob || issue.Severity != SeverityError || issue.ClaimNumber != 1 || issue.Column != "DOB" || issue.Value != "not a date" || issue.Location.Row != 3 {
+			t.Errorf("Expected the invalid dob of claim 1 to exclude it but got %#v", issue)
+		}
+
+		excluded := inputData.trace.Explain("1")
+		if len(excluded) == 0 || excluded[len(excluded)-1].Outcome != "excluded by a data quality issue" {
+			t.Errorf("Expected the exclusion to be traced but got %#v", excluded)
+		}
+	})
+
+	t.Run("Stops the run at an invalid dob", func(t *testing.T) {
+		inputData := newHouseholdInputData(AbortRun)
+
+		_, err := PeopleInHouseholdsWithChildren(inputData, store)
+
+		if _, ok := err.(ErrInvalidValue); !ok {
+			t.Fatalf("Expected ErrInvalidValue but got %#v", err)
+		}
+
+		issues := inputData.quality.Issues()
+		if len(issues) != 1 || issues[0].Severity != SeverityFatal || issues[0].Action != "stopped run" {
+			t.Errorf("Expected the fatal issue to be recorded but got %#v", issues)
+		}
+	})
+
+	t.Run("Uses the policy for the kind of issue", func(t *testing.T) {
+		inputData := newHouseholdInputData(AbortRun)
+		inputData.quality = newDataQuality(map[string]IssuePolicy{IssueInvalidDob: ExcludeClaim}, AbortRun)
+
+		people, err := PeopleInHouseholdsWithChildren(inputData, store)
+
+		if err != nil || len(people) != 1 {
+			t.Errorf("Expected claim 1 to be excluded but got %#v, %#v", people, err)
+		}
+	})
+}
+
+func TestSchoolRollDataQuality(t *testing.T) {
+	newSchoolRollInputData := func(policy IssuePolicy) InputData {
+		return InputData{
+			schoolRoll: spreadsheet.ParserInput{Path: "./testdata/School Roll invalid.csv", HasHeaders: true},
+			quality:    newDataQuality(nil, policy),
+		}
+	}
+
+	t.Run("Skips the pupil with an invalid dob", func(t *testing.T) {
+		inputData := newSchoolRollInputData(ExcludeClaim)
+
+		rows, _, _, err := cacheSchoolRoll(inputData, PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(rows) != 1 || rows[0].Seemis != "5001" {
+			t.Errorf("Expected only pupil 5001 but got %#v", rows)
+		}
+
+		issues := inputData.quality.Issues()
+		if len(issues) != 1 {
+			t.Fatalf("Expected 1 issue but got %#v", issues)
+		}
+		issue := issues[0]
+		if issue.Kind != IssueInvalidDob || issue.Severity != SeverityError || issue.Action != "skipped row" || issue.Column != "Date of Birth" || issue.Value != "not a date" || issue.Location.Row != 3 {
+			t.Errorf("Expected the invalid dob of pupil 5002 to skip it but got %#v", issue)
+		}
+	})
+
+	t.Run("Stops the run at an invalid dob", func(t *testing.T) {
+		inputData := newSchoolRollInputData(AbortRun)
+
+		_, _, _, err := cacheSchoolRoll(inputData, PeopleStore{})
+
+		if _, ok := err.(ErrInvalidValue); !ok {
+			t.Fatalf("Expected ErrInvalidValue but got %#v", err)
+		}
+		if issues := inputData.quality.Issues(); len(issues) != 1 || issues[0].Severity != SeverityFatal {
+			t.Errorf("Expected the fatal issue to be recorded but got %#v", issues)
+		}
+	})
+}
+
+func TestConsentDataQuality(t *testing.T) {
+	inputData := InputData{
+		consent360: spreadsheet.ParserInput{Path: "./testdata/Consent Report invalid.csv", HasHeaders: true},
+		quality:    newDataQuality(nil, AbortRun),
+	}
+
+	consent, err := extractConsentData(inputData)
+
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	if len(consent) != 2 || consent[1001] == "" || consent[1002] == "" {
+		t.Errorf("Expected the consent of claims 1001 and 1002 only but got %#v", consent)
+	}
+
+	issues := inputData.quality.Issues()
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue but got %#v", issues)
+	}
+	issue := issues[0]
+	if issue.Kind != IssueInvalidClaimNumber || issue.Action != "skipped row" || issue.Column != "CLAIMREFERENCE" || issue.Value != "not a claim" || issue.Location.Row != 3 {
+		t.Errorf("Expected the invalid claim reference to be skipped but got %#v", issue)
+	}
+}
+
+func TestIncomeDataQuality(t *testing.T) {
+	// The benefit extract test data is missing the income columns
+	var row spreadsheet.Row
+	err := spreadsheet.EachRow(spreadsheet.ParserInput{Path: "./testdata/Benefit Extract_06_09_19.txt", HasHeaders: true}, func(r spreadsheet.Row) {
+		if row == nil {
+			row = r
+		}
+	})
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	person := Person{ClaimNumber: 17, BenefitExtractRow: row}
+
+	qualify := func(policy IssuePolicy) (InputData, []Person, error) {
+		inputData := InputData{quality: newDataQuality(nil, policy), trace: &Trace{}}
+		ch := make(chan Person, 1)
+		var stopErr error
+		var wg sync.WaitGroup
+		wg.Add(1)
+
+		qualifyPerson(inputData, person, nil, ch, &wg, func(err error) { stopErr = err })
+
+		close(ch)
+		people := []Person{}
+		for p := range ch {
+			people = append(people, p)
+		}
+		return inputData, people, stopErr
+	}
+
+	t.Run("Excludes the claim missing an income column", func(t *testing.T) {
+		inputData, people, err := qualify(ExcludeClaim)
+
+		if err != nil || len(people) != 0 {
+			t.Fatalf("Expected the claim to be excluded but got %#v, %#v", people, err)
+		}
+		issues := inputData.quality.Issues()
+		if len(issues) != 1 || issues[0].Kind != IssueMissingIncome || issues[0].ClaimNumber != 17 {
+			t.Errorf("Expected the missing income to be recorded but got %#v", issues)
+		}
+	})
+
+	t.Run("Stops the run at a missing income column", func(t *testing.T) {
+		_, _, err := qualify(AbortRun)
+
+		if _, ok := err.(spreadsheet.ErrMissingHeader); !ok {
+			t.Errorf("Expected ErrMissingHeader but got %#v", err)
+		}
+	})
+}
+
+func TestWriteDataQualityReport(t *testing.T) {
+	inputData := newInputData(testConfig(t))
+	inputData.outputFolder = t.TempDir()
+	inputData.quality.add(DataIssue{
+		Severity:    SeverityError,
+		Kind:        IssueInvalidAge,
+		Location:    spreadsheet.Location{Path: "shbe.csv", Row: 4},
+		Column:      "Age",
+		Value:       "six",
+		ClaimNumber: 12,
+		Action:      "excluded claim",
+		Reason:      "Unable to parse age",
+	})
+
+	err := WriteDataQualityReport(inputData)
+
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(inputData.outputFolder, "report_data_quality.csv"))
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	if !strings.Contains(string(contents), "error,invalid age,shbe.csv,,4,Age,six,12,excluded claim,Unable to parse age") {
+		t.Errorf("Expected the issue in the report but got %s", contents)
+	}
+}
+
+func TestConfigDataIssues(t *testing.T) {
+	config := testConfig(t)
+	config.DataIssues = map[string]IssuePolicy{"invalid postcode": ExcludeClaim}
+
+	_, err := Run(context.Background(), config)
+
+	if _, ok := err.(ErrUnknownIssueKind); !ok {
+		t.Errorf("Expected ErrUnknownIssueKind but got %#v", err)
+	}
+}
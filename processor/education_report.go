@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// WriteEducationReport writes a sheet of people who were not found in the school roll
+func WriteEducationReport(writer spreadsheet.Writer, inputData InputData, store PeopleStore, sheetName string) error {
+	inputData.log.Infof("Writing education report for %d dependents to %s", len(store.ReportForEducationDependents), sheetName)
+
+	err := writer.AddSheet(sheetName, []string{
+		"claim",
+		"first name",
+		"last name",
+		"date of birth",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range store.ReportForEducationDependents {
+		line := []spreadsheet.Cell{
+			spreadsheet.TextCell(fmt.Sprintf("%d", d.Person.ClaimNumber)),
+			spreadsheet.TextCell(d.Forename),
+			spreadsheet.TextCell(d.Surname),
+			spreadsheet.DateCell(d.Dob, "02-01-2006"),
+		}
+
+		err = writer.Write(line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// ErrMissingInput represents an input file that hasn't been given
+type ErrMissingInput struct {
+	input string
+}
+
+func (e ErrMissingInput) Error() string {
+	return fmt.Sprintf(`Missing input path for the %s`, e.input)
+}
+
+// ErrInvalidOutputFormat represents an output format that isn't supported
+type ErrInvalidOutputFormat struct {
+	format string
+}
+
+func (e ErrInvalidOutputFormat) Error() string {
+	return fmt.Sprintf(`Invalid output format "%s", expected "%s" or "%s"`, e.format, csvOutput, xlsxOutput)
+}
+
+// ErrInvalidValue represents a value in an input that can't be parsed
+type ErrInvalidValue struct {
+	location spreadsheet.Location
+	name     string
+	column   string
+	value    string
+}
+
+func (e ErrInvalidValue) Error() string {
+	return fmt.Sprintf(`Unable to parse %s "%s" at %s`, e.name, e.value, e.location)
+}
+
+// Location returns where the value is in the input
+func (e ErrInvalidValue) Location() spreadsheet.Location {
+	return e.location
+}
+
+// ErrInvalidIssuePolicy represents a data quality issue policy that isn't abort or exclude
+type ErrInvalidIssuePolicy struct {
+	policy string
+}
+
+func (e ErrInvalidIssuePolicy) Error() string {
+	return fmt.Sprintf(`Invalid data issue policy "%s", expected "%s" or "%s"`, e.policy, AbortRun, ExcludeClaim)
+}
+
+// ErrUnknownIssueKind represents a policy given for a kind of data quality issue that policies can't be set for
+type ErrUnknownIssueKind struct {
+	kind string
+}
+
+func (e ErrUnknownIssueKind) Error() string {
+	return fmt.Sprintf(`Unknown kind of data issue "%s", expected one of "%s"`, e.kind, strings.Join(claimIssueKinds, `", "`))
+}
+
+// ErrInvalidThreshold represents a matching threshold that isn't a score above 0 and up to 1
+type ErrInvalidThreshold struct {
+	name  string
+	value float64
+}
+
+func (e ErrInvalidThreshold) Error() string {
+	return fmt.Sprintf(`Invalid %s %g, expected a score above 0 and up to 1`, e.name, e.value)
+}
+
+// ErrUnknownStage represents a stage name in the config that isn't one of the stages of the algorithm
+type ErrUnknownStage struct {
+	algorithm string
+	name      string
+	available []string
+}
+
+func (e ErrUnknownStage) Error() string {
+	return fmt.Sprintf(`Unknown %s stage "%s", expected one of "%s"`, e.algorithm, e.name, strings.Join(e.available, `", "`))
+}
+
+// ErrCancelled is returned by a run stopped by its context being cancelled or its deadline passing
+type ErrCancelled struct {
+	reason error
+}
+
+func (e ErrCancelled) Error() string {
+	return fmt.Sprintf(`Run cancelled: %s`, e.reason)
+}
+
+// Unwrap returns the context's error, so errors.Is can tell if the run was cancelled or timed out
+func (e ErrCancelled) Unwrap() error {
+	return e.reason
+}
+
+// ErrInvalidConfig represents a config file that can't be read
+type ErrInvalidConfig struct {
+	filePath string
+	reason   string
+}
+
+func (e ErrInvalidConfig) Error() string {
+	return fmt.Sprintf(`Invalid config file "%s": %s`, e.filePath, e.reason)
+}
+
+// ErrUnsupportedConfigVersion represents a config file with a version that isn't supported
+type ErrUnsupportedConfigVersion struct {
+	filePath string
+	version  int
+}
+
+func (e ErrUnsupportedConfigVersion) Error() string {
+	return fmt.Sprintf(`Config file "%s" has version %d, expected version %d`, e.filePath, e.version, ConfigVersion)
+}
+
+// ErrInvalidTrace represents a line of a trace file that can't be read
+type ErrInvalidTrace struct {
+	filePath string
+	line     int
+	reason   string
+}
+
+func (e ErrInvalidTrace) Error() string {
+	return fmt.Sprintf(`Invalid trace file "%s" at line %d: %s`, e.filePath, e.line, e.reason)
+}
@@ -1,9 +1,10 @@
-package main
+package processor
 
 import (
 	"encoding/csv"
 	"fmt"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/addjam/fsm-processor/llog"
@@ -11,24 +12,28 @@ import (
 )
 
 // FillExistingGrants iterates over the existing FSM and CG grants
-// and adds the data to appropriate dependents
-func FillExistingGrants(inputData InputData, dependents []Dependent) []Dependent {
+// and adds the data to appropriate dependents. Errors if the awards can't be read.
+func FillExistingGrants(inputData InputData, dependents []Dependent) ([]Dependent, error) {
 	ninoIndex, err := spreadsheet.CreateIndex(inputData.fsmCgAwards, "NI Number", func(nino string) string {
 		return CleanString(nino)
 	})
 
 	if err != nil {
-		return []Dependent{}
+		return dependents, err
 	}
 
 	matches := 0
 
 	var writer *csv.Writer
+	var output *OutputFile
 
 	if inputData.devMode {
-		file, err := os.Create("report_existing_awards_matches.csv")
+		filePath := path.Join(inputData.outputFolder, "report_existing_awards_matches.csv")
+		file, err := os.Create(filePath)
 		if err != nil {
-			llog.Println("Error creating output")
+			inputData.log.With(llog.Fields{"file": filePath}).Warnf("Couldn't create the existing awards matches report: %s", err)
+		} else {
+			output = inputData.record.addOutput(filePath, "")
 		}
 		defer file.Close()
 
@@ -65,7 +70,7 @@ func FillExistingGrants(inputData InputData, dependents []Dependent) []Dependent
 			}
 		}
 
-		isMatch := bestMatchScore >= 0.95
+		isMatch := bestMatchScore >= inputData.awardMatchThreshold
 
 		if isMatch {
 			matches++
@@ -82,6 +87,8 @@ func FillExistingGrants(inputData InputData, dependents []Dependent) []Dependent
 			dependents[index] = d
 		}
 
+		traceExistingGrant(inputData, dependents[index], len(awardRows), bestMatch, bestMatchScore, isMatch)
+
 		// Log the best match
 		if bestMatchScore > 0 && inputData.devMode {
 			writer.Write([]string{
@@ -91,18 +98,42 @@ func FillExistingGrants(inputData InputData, dependents []Dependent) []Dependent
 				dependent.SeemisSurname, spreadsheet.ColByName(bestMatch, "Pupil Surname"),
 				fmt.Sprintf("%f", bestMatchScore), fmt.Sprintf("%f", bestMatchTruncatedScore),
 			})
+			inputData.record.addRows(output, 1)
 		}
 	}
 
-	llog.Printf("matched %d out of %d dependents in fsm/cg awards\n", matches, len(dependents))
-	return dependents
+	inputData.log.Infof("matched %d out of %d dependents in fsm/cg awards", matches, len(dependents))
+	return dependents, nil
+}
+
+// traceExistingGrant records the closest award to the dependent, out of the candidates with the same NINO
+func traceExistingGrant(inputData InputData, d Dependent, candidates int, bestMatch spreadsheet.Row, score float64, isMatch bool) {
+	evidence := Evidence{
+		"awards with the NINO": fmt.Sprintf("%d", candidates),
+		"match threshold":      scoreEvidence(inputData.awardMatchThreshold),
+	}
+
+	if bestMatch != nil {
+		evidence["closest award"] = bestMatch.Location().String()
+		evidence["name score"] = scoreEvidence(score)
+	}
+
+	if !isMatch {
+		inputData.traceDependent(d, "no existing award", evidence)
+		return
+	}
+
+	evidence["FSM approved"] = d.AwardsFsmApproved
+	evidence["payrun date"] = d.AwardsPayrunDate
+	inputData.traceDependent(d, fmt.Sprintf("existing award found, FSM %s, CG %s", yesNoEvidence(d.ExistingFSM), yesNoEvidence(d.ExistingCG)), evidence)
 }
 
-func findDependentIndex(dependents []Dependent, forename, surname, nino string) (int, error) {
+// findDependentIndex returns the index of the dependent with the nino and a name scoring at least the award match threshold
+func findDependentIndex(inputData InputData, dependents []Dependent, forename, surname, nino string) (int, error) {
 	for i, dep := range dependents {
 		forenameScore := CompareCleanedStrings(dep.Forename, forename)
 		surnameScore := CompareCleanedStrings(dep.Surname, surname)
-		hasSimilarName := ((forenameScore + surnameScore) / 2) > 0.95
+		hasSimilarName := ((forenameScore + surnameScore) / 2) >= inputData.awardMatchThreshold
 		if hasSimilarName && CleanString(dep.Person.Nino) == CleanString(nino) {
 			return i, nil
 		}
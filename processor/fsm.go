@@ -0,0 +1,48 @@
+package processor
+
+import "github.com/addjam/fsm-processor/llog"
+
+// FsmStages returns the stages of the FSM algorithm, combining input spreadsheets
+// to find both the dependents to award and those to report to education.
+func FsmStages() []Stage {
+	return []Stage{
+		NewStage(StageConsent, func(inputData InputData, store *PeopleStore) error {
+			return AddPeopleWithConsent(inputData, store)
+		}),
+		NewStage(StageHousehold, householdStage),
+		NewStage(StageIncome, func(inputData InputData, store *PeopleStore) error {
+			people, err := PeopleWithQualifyingIncomes(inputData, *store)
+			if err != nil {
+				return err
+			}
+
+			store.People = people
+			return nil
+		}),
+		NewStage(StageSchoolMatch, schoolMatchStage),
+		NewStage(StageExistingGrants, existingGrantsStage),
+		NewStage(StageNewEntitlements, func(inputData InputData, store *PeopleStore) error {
+			filtered := FilterOnlyNewEntitlements(store.AwardDependents)
+			inputData.traceFilter(store.AwardDependents, filtered, "has new entitlements", "no new entitlements", entitlementEvidence)
+
+			store.AwardDependents = filtered
+			return nil
+		}),
+		NewStage(StageMinimumP1, minimumP1Stage),
+		NewStage(StageExclusionList, exclusionListStage),
+	}
+}
+
+// GenerateFsmAwards runs the stages of the FSM algorithm, returning the store and the counts
+// before and after each stage. Returns the store as it was when an error stopped the algorithm.
+func GenerateFsmAwards(inputData InputData) (PeopleStore, []StageStats, error) {
+	inputData.algorithm = PhaseFsm
+	inputData.log = inputData.log.With(llog.Fields{"algorithm": PhaseFsm})
+
+	store := PeopleStore{}
+	stats, err := runStages(inputData, inputData.fsmStages, &store)
+	if err == nil {
+		inputData.traceLetters(store.AwardDependents)
+	}
+	return store, stats, err
+}
@@ -1,10 +1,11 @@
-package main
+package processor
 
 import (
 	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/addjam/fsm-processor/llog"
 	"github.com/addjam/fsm-processor/spreadsheet"
 	"github.com/jamesturk/go-jellyfish"
 )
@@ -67,8 +68,8 @@ func SplitByMinimumAge(inputData InputData, dependents []Dependent) (atThreshold
 	return
 }
 
-// FilterUsingExclusionList returns only the dependents that aren't in the filter list
-func FilterUsingExclusionList(inputData InputData, dependents []Dependent) []Dependent {
+// FilterUsingExclusionList returns only the dependents that aren't in the filter list. Errors if the list can't be read.
+func FilterUsingExclusionList(inputData InputData, dependents []Dependent) ([]Dependent, error) {
 	result := []Dependent{}
 
 	index, err := spreadsheet.CreateIndex(inputData.filter, "claim ref", func(cellValue string) string {
@@ -76,24 +77,48 @@ func FilterUsingExclusionList(inputData InputData, dependents []Dependent) []Dep
 	})
 
 	if err != nil {
-		return result
+		return dependents, err
 	}
 
 	for _, d := range dependents {
 		claimStr := fmt.Sprintf("%d", d.Person.ClaimNumber)
 		isFiltered := false
+		evidence := Evidence{"filter rows for the claim": "none"}
 		if rows, ok := index[claimStr]; ok {
+			evidence["filter rows for the claim"] = fmt.Sprintf("%d", len(rows))
 			for _, row := range rows {
 				seemis := spreadsheet.ColByName(row, "seemis ID")
 				filteredByThisRow := seemis != "" && seemis == d.Seemis
 				isFiltered = isFiltered || filteredByThisRow
+
+				if filteredByThisRow {
+					evidence["filter row"] = row.Location().String()
+				}
 			}
 		}
 
 		if !isFiltered {
+			inputData.traceDependent(d, "not in the exclusion list", evidence)
 			result = append(result, d)
+		} else {
+			inputData.traceDependent(d, "excluded by the exclusion list", evidence)
 		}
 	}
 
-	return result
+	return result, nil
+}
+
+// rowFields are the log fields locating a row of an input
+func rowFields(row spreadsheet.Row) llog.Fields {
+	location := row.Location()
+	fields := llog.Fields{"file": location.Path, "row": location.Row}
+	if location.Sheet != "" {
+		fields["sheet"] = location.Sheet
+	}
+	return fields
+}
+
+// claimFields are the log fields of a claim
+func claimFields(claimNumber int) llog.Fields {
+	return llog.Fields{"claim_number": claimNumber}
 }
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// PeopleInHouseholdsWithChildren returns only the people in the store that belong to households
+// which have children, with those children added as dependants.
+// Data Source: SHBE
+func PeopleInHouseholdsWithChildren(inputData InputData, store PeopleStore) ([]Person, error) {
+	householdPeopleStore := PeopleStore{}
+
+	// The first issue to stop the run, which stops the remaining rows being checked
+	var valueErr error
+
+	// Claims excluded by a data quality issue, with the issue
+	excluded := map[int]string{}
+
+	err := spreadsheet.EachRow(inputData.dependentsSHBE, func(row spreadsheet.Row) {
+		claimNumStr := row.Col(0)
+		if claimNumStr == "" || valueErr != nil {
+			return
+		}
+
+		claimNumber, err := parseClaimNumber(row, columnName(row, 0), claimNumStr)
+		if err != nil {
+			valueErr = inputData.claimIssue(row, IssueInvalidClaimNumber, 0, err)
+			return
+		}
+
+		if _, ok := excluded[claimNumber]; ok {
+			return
+		}
+
+		// Check our local store, fall back to the overall store
+		person, err := householdPeopleStore.FindByClaimNumber(claimNumber)
+		alreadyAdded := err == nil
+		if err == ErrPersonNotFound {
+			person, err = store.FindByClaimNumber(claimNumber)
+
+			if err == ErrPersonNotFound {
+				return
+			}
+		}
+
+		exclude := func(kind string, invalid error) {
+			valueErr = inputData.claimIssue(row, kind, claimNumber, invalid)
+			excluded[claimNumber] = invalid.Error()
+		}
+
+		ageStr := row.Col(5)
+		age, err := spreadsheet.ParseInt(ageStr)
+		if err != nil {
+			exclude(IssueInvalidAge, ErrInvalidValue{location: row.Location(), name: "age", column: columnName(row, 5), value: ageStr})
+			return
+		}
+
+		dobStr := row.Col(4)
+		dob, err := spreadsheet.ParseDate(dobStr, "01-02-06", "2006-01-02")
+		if err != nil {
+			exclude(IssueInvalidDob, ErrInvalidValue{location: row.Location(), name: "dob", column: columnName(row, 4), value: dobStr})
+			return
+		}
+
+		dependent := Dependent{
+			Forename: row.Col(3),
+			Surname:  row.Col(2),
+			AgeYears: age,
+			Dob:      dob,
+		}
+		person.AddDependent(dependent)
+		inputData.traceDependent(person.Dependents[len(person.Dependents)-1], "child in household", Evidence{
+			"dependents SHBE row": row.Location().String(),
+			"age":                 ageStr,
+			"dob":                 dobStr,
+		})
+
+		if alreadyAdded {
+			householdPeopleStore.Update(person)
+		} else {
+			householdPeopleStore.Add(person)
+		}
+	})
+
+	if err == nil {
+		err = valueErr
+	}
+
+	withChildren := map[int]bool{}
+	people := []Person{}
+	for _, person := range householdPeopleStore.People {
+		withChildren[person.ClaimNumber] = true
+		if _, ok := excluded[person.ClaimNumber]; !ok {
+			people = append(people, person)
+		}
+	}
+	for _, person := range store.People {
+		if issue, ok := excluded[person.ClaimNumber]; ok {
+			inputData.tracePerson(person, "excluded by a data quality issue", Evidence{"issue": issue})
+		} else if !withChildren[person.ClaimNumber] {
+			inputData.tracePerson(person, "no children in household", Evidence{"dependents SHBE rows": "none for the claim number"})
+		}
+	}
+
+	return people, err
+}
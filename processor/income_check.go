@@ -0,0 +1,409 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// Required sheets:
+// - Benefit Extract
+// - dependants shbe
+// - school roll
+// - current awards
+// - hb uc d (universal credit)
+// - eligibility gap list
+
+// incomeData represents the data for a single person
+type incomeData struct {
+	person                 Person
+	taxCreditIncomeStepOne float32
+	taxCreditIncomeStepTwo float32
+	taxCreditFigure        float32
+	combinedQualifier      bool
+	qualifierType          string
+
+	// Evidence used by determineCombinedQualifier
+	wtc                 float32
+	ctc                 float32
+	passportedIndicator string
+	ucBenefitAmount     string // empty if there's no universal credit record
+}
+
+// evidence returns the income data used to qualify the person, for the trace
+func (i incomeData) evidence() Evidence {
+	evidence := Evidence{
+		"step one income":                 moneyEvidence(i.taxCreditIncomeStepOne),
+		"step two income":                 moneyEvidence(i.taxCreditIncomeStepTwo),
+		"tax credit figure":               moneyEvidence(i.taxCreditFigure),
+		"WTC":                             moneyEvidence(i.wtc),
+		"CTC":                             moneyEvidence(i.ctc),
+		"passported / standard claim":     i.passportedIndicator,
+		"universal credit benefit amount": i.ucBenefitAmount,
+	}
+	if i.ucBenefitAmount == "" {
+		evidence["universal credit benefit amount"] = "no universal credit record"
+	}
+	if i.qualifierType != "" {
+		evidence["qualifier"] = i.qualifierType
+	}
+	return evidence
+}
+
+func (i incomeData) String() string {
+	return fmt.Sprintf(
+		"[[[IncomeData:\n%s\nstep one: %f, step two: %f, figure: %f, combined? %t, type %s]]]",
+		i.person.String(),
+		i.taxCreditIncomeStepOne,
+		i.taxCreditIncomeStepTwo,
+		i.taxCreditFigure,
+		i.combinedQualifier,
+		i.qualifierType,
+	)
+}
+
+// PeopleWithQualifyingIncomes returns just the people in the provided store that qualify
+// for FSM or CG. Updates the people to show this.
+func PeopleWithQualifyingIncomes(inputData InputData, store PeopleStore) ([]Person, error) {
+	var people []Person
+
+	universalCreditParser, err := spreadsheet.NewParser(inputData.universalCredit)
+	if err != nil {
+		return people, err
+	}
+
+	var rowsByClaimNum map[int]spreadsheet.Row = make(map[int]spreadsheet.Row)
+	err = spreadsheet.EachParserRow(universalCreditParser, func(r spreadsheet.Row) {
+		// Numeric fields have been validated by the layout
+		claimNum, _ := spreadsheet.IntColByName(r, "Claim Number")
+
+		if rowsByClaimNum[claimNum] != nil {
+			// Ensure we're using the most recent row for this claim number,
+			// the one with the highest sequence number
+			newSequenceNumber, _ := spreadsheet.IntColByName(r, "Sequence Number")
+			existingSequenceNumber, _ := spreadsheet.IntColByName(rowsByClaimNum[claimNum], "Sequence Number")
+
+			if existingSequenceNumber < newSequenceNumber {
+				rowsByClaimNum[claimNum] = r
+			}
+		} else {
+			rowsByClaimNum[claimNum] = r
+		}
+	})
+
+	if err != nil {
+		return people, err
+	}
+
+	var wg sync.WaitGroup
+	qualifyingPeopleChan := make(chan Person)
+
+	// The first issue to stop the run, set by any of the workers
+	var valueErr error
+	var valueErrMu sync.Mutex
+	stop := func(err error) {
+		valueErrMu.Lock()
+		defer valueErrMu.Unlock()
+		if valueErr == nil {
+			valueErr = err
+		}
+	}
+
+	for _, person := range store.People {
+		wg.Add(1)
+		ucRow := rowsByClaimNum[person.ClaimNumber]
+		go qualifyPerson(inputData, person, ucRow, qualifyingPeopleChan, &wg, stop)
+	}
+
+	go func() {
+		wg.Wait()
+		close(qualifyingPeopleChan)
+	}()
+
+	for person := range qualifyingPeopleChan {
+		people = append(people, person)
+	}
+
+	if valueErr != nil {
+		return people, valueErr
+	}
+	return people, inputData.cancelled()
+}
+
+// AddPeopleWithCtr adds people to the store who are receiging a
+// weekly cts entitlement greater than 0
+func AddPeopleWithCtr(inputData InputData, store *PeopleStore) error {
+	// The first issue to stop the run, which stops the remaining rows being checked
+	var valueErr error
+
+	err := spreadsheet.EachRow(inputData.benefitExtract, func(r spreadsheet.Row) {
+		if valueErr != nil {
+			return
+		}
+
+		weeklyCtsEntitlement := spreadsheet.FloatColByName(r, "Weekly CTS entitlement")
+
+		claim := Person{Nino: spreadsheet.ColByName(r, "NINO")}
+		claim.ClaimNumber, _ = strconv.Atoi(spreadsheet.ColByName(r, "Claim Number"))
+		evidence := Evidence{"weekly CTS entitlement": moneyEvidence(weeklyCtsEntitlement), "benefit extract row": r.Location().String()}
+
+		if weeklyCtsEntitlement <= 0.0 {
+			inputData.tracePerson(claim, "no CTS entitlement", evidence)
+			return
+		}
+
+		if strings.TrimSpace(spreadsheet.ColByName(r, "Claim Number")) == "" {
+			inputData.rowIssue(r, IssueMissingClaimNumber, "Claim Number", "No claim number in the benefit extract")
+			return
+		}
+
+		person, err := NewPersonFromBenefitExtract(r)
+
+		if err != nil {
+			valueErr = inputData.claimIssue(r, IssueInvalidClaimNumber, 0, err)
+			return
+		}
+
+		inputData.tracePerson(claim, "receiving CTS", evidence)
+		store.Add(person)
+	})
+
+	if err == nil {
+		err = valueErr
+	}
+	return err
+}
+
+// Concurrently qualifies person based on icnome data. An issue that should stop the run is passed to stop.
+func qualifyPerson(inputData InputData, p Person, universalCreditRow spreadsheet.Row, ch chan Person, w *sync.WaitGroup, stop func(error)) {
+	defer w.Done()
+
+	// Workers still waiting to start once the run is cancelled do nothing
+	if inputData.cancelled() != nil {
+		return
+	}
+
+	// Calculate step one/two data
+	incomeData, err := calculateIncomeSteps(inputData, p)
+	if err != nil {
+		if stopErr := inputData.claimIssue(p.BenefitExtractRow, IssueMissingIncome, p.ClaimNumber, err); stopErr != nil {
+			stop(stopErr)
+			return
+		}
+
+		inputData.tracePerson(p, "excluded by a data quality issue", Evidence{"issue": err.Error()})
+		return
+	}
+
+	// Calculate tax credit figure
+	if incomeData.taxCreditIncomeStepOne <= inputData.pensionAllowance {
+		incomeData.taxCreditFigure = incomeData.taxCreditIncomeStepTwo * 52
+	} else {
+		incomeData.taxCreditFigure = (incomeData.taxCreditIncomeStepTwo - inputData.pensionAllowance) * 52
+	}
+
+	if incomeData.taxCreditFigure < 0 {
+		incomeData.taxCreditFigure = 0
+	}
+
+	// Check for FSM & CG combined qualification
+	determineCombinedQualifier(inputData, p, &incomeData, universalCreditRow)
+
+	debugLog := inputData.log.With(claimFields(p.ClaimNumber))
+	if p.ClaimNumber == inputData.debugClaimNumber {
+		debugLog.Infof("Qualifying debug target")
+		debugLog.Infof("%s", incomeData.String())
+	}
+
+	if incomeData.combinedQualifier {
+		p.QualiferType = incomeData.qualifierType
+		for i, d := range p.Dependents {
+			d.NewCG = inputData.awardCG
+			d.NewFSM = true
+			d.Person = p
+			p.Dependents[i] = d
+		}
+
+		if p.ClaimNumber == inputData.debugClaimNumber {
+			debugLog.Infof("Awarded FSM to target claim number: true")
+			debugLog.Infof("Awarded CG to target claim number: %t", inputData.awardCG)
+		}
+
+		inputData.tracePerson(p, "qualifies for FSM and CG", incomeData.evidence())
+		ch <- p
+		return
+	}
+
+	// Check for CG-only qualification via weekly cts entitlement being greater than 0.0
+	weeklyCtsEntitlement := spreadsheet.FloatColByName(p.BenefitExtractRow, "Weekly CTS entitlement")
+	if p.ClaimNumber == inputData.debugClaimNumber {
+		debugLog.Infof("Weekly cts entitlement for debug target is %f", weeklyCtsEntitlement)
+	}
+
+	evidence := incomeData.evidence()
+	evidence["weekly CTS entitlement"] = moneyEvidence(weeklyCtsEntitlement)
+
+	if weeklyCtsEntitlement > 0.0 {
+		for i, d := range p.Dependents {
+			d.NewCG = inputData.awardCG
+			d.Person = p
+			p.Dependents[i] = d
+		}
+
+		inputData.tracePerson(p, "qualifies for CG only, receiving CTS", evidence)
+		ch <- p
+		return
+	}
+
+	inputData.tracePerson(p, "doesn't qualify", evidence)
+}
+
+// calculateIncomeSteps returns the step one and two incomes of the person, or an error if an income column is missing
+func calculateIncomeSteps(inputData InputData, person Person) (incomeData, error) {
+	stepOne, err := calculateStepOne(inputData, person)
+	if err != nil {
+		return incomeData{}, err
+	}
+
+	stepTwo, err := calculateStepTwo(inputData, person)
+	if err != nil {
+		return incomeData{}, err
+	}
+
+	return incomeData{
+		person:                 person,
+		taxCreditIncomeStepOne: stepOne,
+		taxCreditIncomeStepTwo: stepTwo,
+	}, nil
+}
+
+func calculateStepOne(inputData InputData, person Person) (float32, error) {
+	colNames := []string{
+		"Clmt Personal Pension",
+		"Clmt State Retirement Pension (incl SERP's graduated pension etc)",
+		"Ptnr Personal Pension",
+		"Ptnr State Retirement Pension (incl SERP's graduated pension etc)",
+		"Clmt Occupational Pension",
+		"Ptnr Occupational Pension",
+	}
+	return sumFloatColumns(inputData, person.BenefitExtractRow, colNames)
+}
+
+func calculateStepTwo(inputData InputData, person Person) (float32, error) {
+	colNames := []string{
+		"Clmt AIF",
+		"Clmt Employment (gross)",
+		"Clmt Self-employment (gross)",
+		"Clmt Student Grant/Loan",
+		"Clmt Sub-tenants",
+		"Clmt Boarders",
+		"Clmt Government Training",
+		"Clmt Statutory Sick Pay",
+		"Clmt Widowed Parent's Allowance",
+		"Clmt Apprenticeship",
+		"Clmt Statutory Sick Pay",
+		"Other weekly Income including In-Work Credit",
+		"Ptnr AIF",
+		"Ptnr Employment (gross)",
+		"Ptnr Self-employment (gross)",
+		"Ptnr Student Grant/Loan",
+		"Ptnr Sub-tenants",
+		"Ptnr Boarders",
+		"Ptnr Training for Work/Community Action",
+		"Ptnr New Deal 50+ Employment Credit",
+		"Ptnr Government Training",
+		"Ptnr Carer's Allowance",
+		"Ptnr Statutory Sick Pay",
+		"Ptnr Widowed Parent's Allowance",
+		"Ptnr Apprenticeship",
+		"Other weekly Income including In-Work Credit",
+		"Clmt Savings Credit",
+		"Ptnr Savings Credit",
+		"Clmt Widows Benefit",
+		"Ptnr Widows Benefit",
+	}
+	return sumFloatColumns(inputData, person.BenefitExtractRow, colNames)
+}
+
+// determineCombinedQualifier sets whether the person qualifies for FSM and CG, and the evidence used, in incomeData
+func determineCombinedQualifier(inputData InputData, p Person, incomeData *incomeData, universalCreditRow spreadsheet.Row) {
+	row := p.BenefitExtractRow
+
+	wtc := spreadsheet.FloatColByName(row, "Clmt Working Tax Credits") + spreadsheet.FloatColByName(row, "Ptnr Working Tax Credits")
+	ctc := spreadsheet.FloatColByName(row, "Child tax credit - Claimant") + spreadsheet.FloatColByName(row, "Child tax credit - Partner")
+
+	qualifierA := wtc == 0 && ctc > 0 && incomeData.taxCreditFigure <= inputData.ctcFigure
+
+	qualifierB := wtc > 0 && ctc > 0 && incomeData.taxCreditFigure <= inputData.ctcWtcFigure
+
+	passportedStdClaimIndicator := spreadsheet.ColByName(row, "Passported / Standard claim indicator")
+	passportQualifier := false
+	for _, indicator := range inputData.passportedIndicators {
+		passportQualifier = passportQualifier || passportedStdClaimIndicator == indicator
+	}
+
+	ucQualifier := false
+	if universalCreditRow != nil {
+		benefitAmountStr := spreadsheet.ColByName(universalCreditRow, "Benefit Amount")
+		incomeData.ucBenefitAmount = benefitAmountStr
+		benefitAmount, err := spreadsheet.MoneyColByName(universalCreditRow, "Benefit Amount")
+		if p.ClaimNumber == inputData.debugClaimNumber {
+			inputData.log.With(claimFields(p.ClaimNumber)).Infof("Benefit amount for debug target is (str) %s / (float) %f", benefitAmountStr, benefitAmount)
+		}
+		if err == nil {
+			ucQualifier = benefitAmount < inputData.benefitAmount
+		}
+	}
+
+	qualifies := qualifierA || qualifierB || passportQualifier || ucQualifier
+
+	qualifyType := ""
+	if qualifierA {
+		qualifyType = "CTC ONLY"
+	} else if qualifierB {
+		qualifyType = "CTC & WTC"
+	} else if passportQualifier {
+		qualifyType = "PASSPORTED"
+	} else if ucQualifier {
+		qualifyType = "UC QUALIFIER"
+	}
+
+	if p.ClaimNumber == inputData.debugClaimNumber {
+		debugLog := inputData.log.With(claimFields(p.ClaimNumber))
+		debugLog.Infof("Determined qualifier for debug target is %s", qualifyType)
+		debugLog.Infof("WTC: %f, CTC: %f, Passported Claim Indicator %s", wtc, ctc, passportedStdClaimIndicator)
+	}
+
+	incomeData.wtc = wtc
+	incomeData.ctc = ctc
+	incomeData.passportedIndicator = passportedStdClaimIndicator
+	incomeData.combinedQualifier = qualifies
+	incomeData.qualifierType = qualifyType
+}
+
+// sumFloatColumns returns the total of the columns in the row. Empty and invalid cells count as 0, but a missing
+// column is returned as an error, as the income it holds can't be counted and the claim could wrongly qualify.
+func sumFloatColumns(inputData InputData, row spreadsheet.Row, colNames []string) (float32, error) {
+	var result float32 = 0
+	for _, colName := range colNames {
+		value, err := spreadsheet.MoneyColByName(row, colName)
+
+		switch err.(type) {
+		case nil:
+		case spreadsheet.ErrMissingHeader:
+			return 0, err
+		case spreadsheet.ErrEmptyCell:
+			// Default to 0 for empty cells
+			value = 0
+		default:
+			inputData.log.With(rowFields(row)).Warnf("Error parsing float, falling back to 0: %s", err.Error())
+			spreadsheet.ReportReplacedCell(row, colName, "0", err)
+			value = 0
+		}
+		result += value
+	}
+	return result, nil
+}
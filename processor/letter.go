@@ -1,4 +1,4 @@
-package main
+package processor
 
 // Letter represents all the types of letters that can be sent
 type Letter int
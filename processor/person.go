@@ -1,9 +1,7 @@
-package main
+package processor
 
 import (
 	"fmt"
-	"log"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,8 +17,9 @@ type Person struct {
 	AddressStreet string
 	Postcode      string
 
-	ConsentDesc  string
-	QualiferType string
+	ConsentDesc    string
+	ConsentRemoved bool // if ConsentDesc is the description of removed consent
+	QualiferType   string
 
 	BenefitExtractRow spreadsheet.Row
 	Dependents        []Dependent
@@ -56,11 +55,8 @@ type Dependent struct {
 
 // NewPersonFromBenefitExtract creates a person based on the provided benefit extract row
 func NewPersonFromBenefitExtract(r spreadsheet.Row) (Person, error) {
-	claimNumStr := spreadsheet.ColByName(r, "Claim Number")
-	claimNumber, err := strconv.Atoi(claimNumStr)
-
+	claimNumber, err := parseClaimNumber(r, "Claim Number", spreadsheet.ColByName(r, "Claim Number"))
 	if err != nil {
-		log.Printf("Error parsing claim number from benefits extract %s", claimNumStr)
 		return Person{}, err
 	}
 
@@ -83,7 +79,7 @@ func (p Person) String() string {
 func (p Person) ConsentStr() string {
 	if p.ConsentDesc == "" {
 		return "Absent"
-	} else if p.ConsentDesc == "FSM&CG Consent Removed" {
+	} else if p.ConsentRemoved {
 		return "Refused"
 	} else {
 		return "Given"
@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/addjam/fsm-processor/llog"
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// Names of the default stages
+const (
+	StageConsent         = "consent"
+	StageCtr             = "CTR"
+	StageHousehold       = "household"
+	StageIncome          = "income"
+	StageCGEligible      = "CG eligible"
+	StageSchoolMatch     = "school match"
+	StageExistingGrants  = "existing grants"
+	StageNewEntitlements = "new entitlements"
+	StageNotReceivingCG  = "not receiving CG"
+	StageMinimumP1       = "P1"
+	StageExclusionList   = "exclusion list"
+	StageFsmAwards       = "FSM awards"
+)
+
+// Stage is a step of the FSM or CTR algorithm, adding, updating or filtering the people and dependents in the store
+type Stage interface {
+	Name() string
+	Run(inputData InputData, store *PeopleStore) error
+}
+
+// stage is a Stage that runs a function
+type stage struct {
+	name string
+	run  func(inputData InputData, store *PeopleStore) error
+}
+
+// StoreCounts are the number of people and dependents in a PeopleStore
+type StoreCounts struct {
+	People              int `json:"people"`
+	AwardDependents     int `json:"award_dependents"`
+	EducationDependents int `json:"education_dependents"`
+}
+
+// StageStats are the counts of a store before and after a stage was run, and how long it took
+type StageStats struct {
+	Stage    string        `json:"stage"`
+	Before   StoreCounts   `json:"before"`
+	After    StoreCounts   `json:"after"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// NewStage creates a Stage that runs the function, used to add stages to the FSM or CTR algorithm
+func NewStage(name string, run func(inputData InputData, store *PeopleStore) error) Stage {
+	return stage{name: name, run: run}
+}
+
+// Name returns the name of the stage
+func (s stage) Name() string {
+	return s.name
+}
+
+// Run runs the stage on the store
+func (s stage) Run(inputData InputData, store *PeopleStore) error {
+	return s.run(inputData, store)
+}
+
+// stagesNamed returns the stages with the names, in the order of the names. All of the stages are returned if
+// there are no names. Errors if a name isn't one of the stages of the algorithm.
+func stagesNamed(algorithm string, stages []Stage, names []string) ([]Stage, error) {
+	if len(names) == 0 {
+		return stages, nil
+	}
+
+	named := []Stage{}
+	for _, name := range names {
+		found := false
+		for _, stage := range stages {
+			if stage.Name() == name {
+				named = append(named, stage)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			available := make([]string, len(stages))
+			for i, stage := range stages {
+				available[i] = stage.Name()
+			}
+			return nil, ErrUnknownStage{algorithm: algorithm, name: name, available: available}
+		}
+	}
+
+	return named, nil
+}
+
+// Config returns the config of the run, so a stage can read its options
+func (i InputData) Config() Config {
+	return i.config
+}
+
+// Input returns the input with the name, e.g. InputBenefitExtract, ready to be read with spreadsheet.EachRow.
+// Its rows are shared with the other stages reading it. Returns false if there's no input with the name.
+func (i InputData) Input(name string) (spreadsheet.ParserInput, bool) {
+	for index, input := range i.inputs() {
+		if inputNames[index] == name {
+			return *input, true
+		}
+	}
+
+	return spreadsheet.ParserInput{}, false
+}
+
+// Log returns the logger of the stage being run
+func (i InputData) Log() *llog.Logger {
+	return i.log
+}
+
+// Context returns the context of the run, a stage that takes a while should stop once it's done
+func (i InputData) Context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// runStages runs each stage in order on the store, recording the counts before and after each.
+// Stops at the first stage to return an error, which isn't included in the stats, or once the run is cancelled.
+func runStages(inputData InputData, stages []Stage, store *PeopleStore) ([]StageStats, error) {
+	stats := []StageStats{}
+	log := inputData.log
+
+	for _, stage := range stages {
+		err := inputData.cancelled()
+		if err != nil {
+			return stats, err
+		}
+
+		before := countStore(*store)
+		start := time.Now()
+		inputData.stage = stage.Name()
+		inputData.log = log.With(llog.Fields{"stage": stage.Name()})
+		inputData.stageEvent(Event{Event: EventStageStarted})
+
+		err = stage.Run(inputData, store)
+		if err == nil {
+			// The stage may have stopped early without an error, leaving the store incomplete
+			err = inputData.cancelled()
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		after := countStore(*store)
+		stats = append(stats, StageStats{Stage: stage.Name(), Before: before, After: after, Duration: time.Since(start)})
+		inputData.stageEvent(Event{Event: EventStageFinished, Duration: time.Since(start)})
+
+		if before.People > 0 && after.People == 0 {
+			inputData.log.Warnf("The %s stage left no people, check its inputs", stage.Name())
+		}
+
+		inputData.log.Infof("%s: %d people, %d dependents to award, %d to report to education",
+			stage.Name(), after.People, after.AwardDependents, after.EducationDependents)
+	}
+
+	return stats, nil
+}
+
+func countStore(store PeopleStore) StoreCounts {
+	return StoreCounts{
+		People:              len(store.People),
+		AwardDependents:     len(store.AwardDependents),
+		EducationDependents: len(store.ReportForEducationDependents),
+	}
+}
+
+// Stages used by both the FSM and CTR algorithms
+
+func householdStage(inputData InputData, store *PeopleStore) error {
+	people, err := PeopleInHouseholdsWithChildren(inputData, *store)
+	if err != nil {
+		return err
+	}
+
+	store.People = people
+	return nil
+}
+
+func schoolMatchStage(inputData InputData, store *PeopleStore) error {
+	nlcDependents, nonNlcDependents, err := PeopleWithChildrenAtNlcSchool(inputData, *store)
+	if err != nil {
+		return err
+	}
+
+	store.ReportForEducationDependents = nonNlcDependents
+	store.AwardDependents = nlcDependents
+	return nil
+}
+
+func existingGrantsStage(inputData InputData, store *PeopleStore) error {
+	dependents, err := FillExistingGrants(inputData, store.AwardDependents)
+	if err != nil {
+		return err
+	}
+
+	store.AwardDependents = dependents
+	return nil
+}
+
+func minimumP1Stage(inputData InputData, store *PeopleStore) error {
+	filtered := FilterMinimumP1(store.AwardDependents)
+	inputData.traceFilter(store.AwardDependents, filtered, "in P1 or above", "below P1", func(d Dependent) Evidence {
+		return Evidence{"year group": d.YearGroup}
+	})
+
+	store.AwardDependents = filtered
+	return nil
+}
+
+// entitlementEvidence is the evidence for stages filtering on the entitlements of dependents
+func entitlementEvidence(d Dependent) Evidence {
+	return Evidence{
+		"existing FSM": yesNoEvidence(d.ExistingFSM),
+		"existing CG":  yesNoEvidence(d.ExistingCG),
+		"new FSM":      yesNoEvidence(d.NewFSM),
+		"new CG":       yesNoEvidence(d.NewCG),
+	}
+}
+
+// exclusionListStage filters both the dependents to award and those to report to education
+func exclusionListStage(inputData InputData, store *PeopleStore) error {
+	awardDependents, err := FilterUsingExclusionList(inputData, store.AwardDependents)
+	if err != nil {
+		return err
+	}
+
+	educationDependents, err := FilterUsingExclusionList(inputData, store.ReportForEducationDependents)
+	if err != nil {
+		return err
+	}
+
+	store.AwardDependents = awardDependents
+	store.ReportForEducationDependents = educationDependents
+	return nil
+}
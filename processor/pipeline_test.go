@@ -0,0 +1,198 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/addjam/fsm-processor/llog"
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+func TestRunStages(t *testing.T) {
+	addPerson := NewStage("add", func(inputData InputData, store *PeopleStore) error {
+		store.Add(Person{Dependents: []Dependent{{}, {}}})
+		return nil
+	})
+	awardDependents := NewStage("award", func(inputData InputData, store *PeopleStore) error {
+		store.AwardDependents = store.People[0].Dependents
+		return nil
+	})
+	errFailed := errors.New("failed")
+	fail := NewStage("fail", func(inputData InputData, store *PeopleStore) error {
+		return errFailed
+	})
+
+	t.Run("Records the counts before and after each stage", func(t *testing.T) {
+		store := PeopleStore{}
+		stats, err := runStages(InputData{}, []Stage{addPerson, addPerson, awardDependents}, &store)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		expected := []StageStats{
+			{Stage: "add", Before: StoreCounts{}, After: StoreCounts{People: 1}},
+			{Stage: "add", Before: StoreCounts{People: 1}, After: StoreCounts{People: 2}},
+			{Stage: "award", Before: StoreCounts{People: 2}, After: StoreCounts{People: 2, AwardDependents: 2}},
+		}
+		if len(stats) != len(expected) {
+			t.Fatalf("Expected %d stages but got %#v", len(expected), stats)
+		}
+		for i := range expected {
+			stats[i].Duration = 0
+			if stats[i] != expected[i] {
+				t.Errorf("Expected %#v but got %#v", expected[i], stats[i])
+			}
+		}
+	})
+
+	t.Run("Stops at the first error", func(t *testing.T) {
+		store := PeopleStore{}
+		stats, err := runStages(InputData{}, []Stage{addPerson, fail, addPerson}, &store)
+
+		if err != errFailed {
+			t.Fatalf("Expected the stage's error but got %#v", err)
+		}
+		if len(stats) != 1 || len(store.People) != 1 {
+			t.Errorf("Expected only the first stage to run but got %#v", stats)
+		}
+	})
+
+	t.Run("Runs the stages in the config", func(t *testing.T) {
+		config := testConfig(t)
+		config.FsmStages = []Stage{addPerson, fail}
+
+		result, err := Run(context.Background(), config)
+
+		if err != errFailed {
+			t.Fatalf("Expected the stage's error but got %#v", err)
+		}
+		if len(result.FsmFunnel) != 1 || result.FsmFunnel[0].Stage != "add" {
+			t.Errorf("Expected the funnel of the configured stages but got %#v", result.FsmFunnel)
+		}
+	})
+
+	t.Run("Runs the stages named in the config", func(t *testing.T) {
+		config := testConfig(t)
+		config.FsmStages = []Stage{addPerson, fail, awardDependents}
+		config.FsmStageNames = []string{"add", "award"}
+		// The dependents added have no rows to write in the reports
+		config.CtrStages = []Stage{fail}
+
+		result, err := Run(context.Background(), config)
+
+		if err != errFailed {
+			t.Fatalf("Expected the CTR stage's error but got %#v", err)
+		}
+		if len(result.FsmFunnel) != 2 || result.FsmFunnel[1].Stage != "award" {
+			t.Errorf("Expected the funnel of the named stages but got %#v, %#v", result.FsmFunnel, err)
+		}
+	})
+
+	t.Run("Rejects an unknown stage name", func(t *testing.T) {
+		config := testConfig(t)
+		config.CtrStageNames = []string{StageCtr, "postcode check"}
+
+		_, err := Run(context.Background(), config)
+
+		if _, ok := err.(ErrUnknownStage); !ok {
+			t.Fatalf("Expected ErrUnknownStage but got %#v", err)
+		}
+	})
+
+	t.Run("Gives custom stages the inputs and options of the run", func(t *testing.T) {
+		config := testConfig(t)
+		config.AwardCG = false
+		var claims []string
+		var awardCG bool
+		readClaims := NewStage("read claims", func(inputData InputData, store *PeopleStore) error {
+			awardCG = inputData.Config().AwardCG
+			input, ok := inputData.Input(InputBenefitExtract)
+			if !ok {
+				return errors.New("no benefit extract")
+			}
+
+			inputData.Log().Infof("Reading %s", input.Path)
+			return spreadsheet.EachRow(input, func(r spreadsheet.Row) {
+				claims = append(claims, spreadsheet.ColByName(r, "Claim Number"))
+			})
+		})
+
+		_, err := runStages(newInputData(config), []Stage{readClaims}, &PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(claims) == 0 || claims[0] != "1001" || awardCG {
+			t.Errorf("Expected the claims of the benefit extract and the options but got %v, %t", claims, awardCG)
+		}
+	})
+
+	t.Run("Stops once the run is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelRun := NewStage("cancel", func(inputData InputData, store *PeopleStore) error {
+			cancel()
+			return nil
+		})
+		inputData := InputData{}.withContext(ctx)
+
+		stats, err := runStages(inputData, []Stage{addPerson, cancelRun, addPerson}, &PeopleStore{})
+
+		if err != context.Canceled {
+			t.Fatalf("Expected the context's error but got %#v", err)
+		}
+		if len(stats) != 1 {
+			t.Errorf("Expected only the first stage to be finished but got %#v", stats)
+		}
+	})
+
+	t.Run("Warns when a stage leaves no people", func(t *testing.T) {
+		removePeople := NewStage("remove", func(inputData InputData, store *PeopleStore) error {
+			store.People = nil
+			return nil
+		})
+		inputData := InputData{log: llog.New(nil, llog.LevelInfo)}
+
+		_, err := runStages(inputData, []Stage{addPerson, removePeople}, &PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		summary := inputData.log.Summary()
+		if summary.Warnings != 1 || summary.Entries[0].Fields["stage"] != "remove" {
+			t.Errorf("Expected a warning for the remove stage but got %#v", summary)
+		}
+	})
+}
+
+func TestStageInputErrors(t *testing.T) {
+	store := PeopleStore{AwardDependents: []Dependent{{Seemis: "1"}}}
+	missing := spreadsheet.ParserInput{Path: "./testdata/missing.csv", HasHeaders: true}
+
+	t.Run("Existing grants fails when the awards can't be read", func(t *testing.T) {
+		inputData := InputData{fsmCgAwards: missing}
+
+		err := existingGrantsStage(inputData, &store)
+
+		if _, ok := err.(spreadsheet.ErrUnableToParse); !ok {
+			t.Errorf("Expected ErrUnableToParse but got %#v", err)
+		}
+		if len(store.AwardDependents) != 1 {
+			t.Errorf("Expected the award list to be unchanged but got %#v", store.AwardDependents)
+		}
+	})
+
+	t.Run("Exclusion list fails when the list can't be read", func(t *testing.T) {
+		inputData := InputData{filter: missing}
+
+		err := exclusionListStage(inputData, &store)
+
+		if _, ok := err.(spreadsheet.ErrUnableToParse); !ok {
+			t.Errorf("Expected ErrUnableToParse but got %#v", err)
+		}
+		if len(store.AwardDependents) != 1 {
+			t.Errorf("Expected the award list to be unchanged but got %#v", store.AwardDependents)
+		}
+	})
+}
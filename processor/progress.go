@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Types of progress events
+const (
+	EventStageStarted    = "stage started"
+	EventStageFinished   = "stage finished"
+	EventRowsParsed      = "rows parsed"  // every spreadsheet.ProgressInterval rows of an input
+	EventInputParsed     = "input parsed" // once an input has been read, with its total rows
+	EventMatchesProgress = "matches"      // dependents compared to the school roll out of the total
+	EventFileWritten     = "file written"
+)
+
+// matchProgressInterval is the number of dependents compared to the school roll between progress events
+const matchProgressInterval = 100
+
+// Event is a step in the progress of a run, so a long running job can show how far through it is
+type Event struct {
+	Time      time.Time     `json:"time"`
+	Event     string        `json:"event"`
+	Algorithm string        `json:"algorithm,omitempty"` // FSM or CTR
+	Stage     string        `json:"stage,omitempty"`
+	Input     string        `json:"input,omitempty"`
+	Path      string        `json:"path,omitempty"`
+	Sheet     string        `json:"sheet,omitempty"`
+	Rows      int           `json:"rows,omitempty"`  // rows parsed so far, or written to a file
+	Done      int           `json:"done,omitempty"`  // matches completed
+	Total     int           `json:"total,omitempty"` // matches to complete
+	Duration  time.Duration `json:"duration_ns,omitempty"`
+}
+
+// progress writes the events of a run as newline delimited json, one event per line. It's shared by
+// copies of the run's InputData, is safe for concurrent use, and a nil progress writes nothing.
+type progress struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// newProgress creates a progress writing to out, returns nil if out is nil
+func newProgress(out io.Writer) *progress {
+	if out == nil {
+		return nil
+	}
+
+	return &progress{encoder: json.NewEncoder(out)}
+}
+
+// emit writes the event, it's timestamped if it has no time
+func (p *progress) emit(event Event) {
+	if p == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Progress is best effort, a reader that's gone away shouldn't stop the run
+	p.encoder.Encode(event)
+}
+
+// stageEvent emits an event about the stage being run
+func (i InputData) stageEvent(event Event) {
+	event.Algorithm = i.algorithm
+	event.Stage = i.stage
+	i.progress.emit(event)
+}
+
+// inputProgress returns the Progress func of an input, emitting the rows parsed
+func inputProgress(p *progress, name string, path string) func(rows int, done bool) {
+	if p == nil {
+		return nil
+	}
+
+	return func(rows int, done bool) {
+		event := EventRowsParsed
+		if done {
+			event = EventInputParsed
+		}
+		p.emit(Event{Event: event, Input: name, Path: path, Rows: rows})
+	}
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// readEvents reads the progress events written as json lines
+func readEvents(t *testing.T, buffer *bytes.Buffer) []Event {
+	t.Helper()
+
+	events := []Event{}
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		event := Event{}
+		err := json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			t.Fatalf("Expected json lines but got %s", scanner.Text())
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestProgress(t *testing.T) {
+	t.Run("Emits the start and finish of each stage", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		inputData := InputData{algorithm: PhaseFsm, progress: newProgress(buffer)}
+		noop := NewStage("noop", func(inputData InputData, store *PeopleStore) error { return nil })
+
+		_, err := runStages(inputData, []Stage{noop}, &PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		events := readEvents(t, buffer)
+		if len(events) != 2 || events[0].Event != EventStageStarted || events[1].Event != EventStageFinished {
+			t.Fatalf("Expected the stage to start then finish but got %#v", events)
+		}
+		if events[1].Algorithm != PhaseFsm || events[1].Stage != "noop" || events[1].Time.IsZero() {
+			t.Errorf("Expected the algorithm, stage and time of the event but got %#v", events[1])
+		}
+	})
+
+	t.Run("Emits the rows parsed from each input", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		config := testConfig(t)
+		config.Progress = buffer
+		inputData := newInputData(config)
+
+		err := AddPeopleWithConsent(inputData, &PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		events := readEvents(t, buffer)
+		if len(events) != 2 {
+			t.Fatalf("Expected an event for the consent and benefit extract inputs but got %#v", events)
+		}
+		for _, event := range events {
+			if event.Event != EventInputParsed || event.Rows == 0 || event.Input == "" {
+				t.Errorf("Expected the rows of the input but got %#v", event)
+			}
+		}
+	})
+
+	t.Run("Emits each file written with its rows", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		inputData := newInputData(testConfig(t))
+		inputData.outputFolder = t.TempDir()
+		inputData.progress = newProgress(buffer)
+
+		err := WriteReports(inputData, PeopleStore{}, PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		events := readEvents(t, buffer)
+		if len(events) != 6 {
+			t.Fatalf("Expected an event for each of the 6 files but got %#v", events)
+		}
+		if summary := events[5]; summary.Event != EventFileWritten || summary.Rows != 10 {
+			t.Errorf("Expected the summary to be written last with 10 rows but got %#v", summary)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+package processor
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// Output formats of the reports
+const (
+	csvOutput  = "csv"
+	xlsxOutput = "xlsx"
+)
+
+// Names of the report sheets
+const (
+	fsmAwardsSheet    = "FSM awards"
+	ctrAwardsSheet    = "CTR awards"
+	fsmEducationSheet = "FSM education report"
+	ctrEducationSheet = "CTR education report"
+	summarySheet      = "Summary"
+	dataQualitySheet  = "Data quality"
+)
+
+// reportWorkbook is the file name of the workbook written in xlsx format
+const reportWorkbook = "report.xlsx"
+
+// csvReportFiles are the file names each sheet is written to in csv format
+var csvReportFiles = map[string]string{
+	fsmAwardsSheet:    "report_awards_fsm.csv",
+	ctrAwardsSheet:    "report_awards_ctr.csv",
+	fsmEducationSheet: "report_education_fsm.csv",
+	ctrEducationSheet: "report_education_ctr.csv",
+	summarySheet:      "report_summary.csv",
+	dataQualitySheet:  "report_data_quality.csv",
+}
+
+// WriteReports writes the award lists and reports for education of both stores, and a summary of them,
+// to the output folder. In xlsx format they're sheets of a single workbook, otherwise a csv file each.
+func WriteReports(inputData InputData, fsmStore PeopleStore, ctrStore PeopleStore) error {
+	writer := newReportWriter(inputData)
+
+	err := writeReportSheets(writer, inputData, fsmStore, ctrStore)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// newReportWriter creates a writer for the output format, recording the files it writes
+func newReportWriter(inputData InputData) spreadsheet.Writer {
+	if inputData.outputFormat == xlsxOutput {
+		filePath := path.Join(inputData.outputFolder, reportWorkbook)
+		inputData.log.Infof("Outputting reports to %s", filePath)
+		return &recordingWriter{
+			Writer:   spreadsheet.NewXlsxWriter(filePath),
+			record:   inputData.record,
+			progress: inputData.progress,
+			pathForSheet: func(sheet string) (string, string) {
+				return filePath, sheet
+			},
+		}
+	}
+
+	csvPath := func(sheet string) string {
+		return path.Join(inputData.outputFolder, csvReportFiles[sheet])
+	}
+
+	return &recordingWriter{
+		Writer: spreadsheet.NewCsvWriter(func(sheet string) string {
+			inputData.log.Infof("Outputting %s to %s", sheet, csvPath(sheet))
+			return csvPath(sheet)
+		}),
+		record:   inputData.record,
+		progress: inputData.progress,
+		pathForSheet: func(sheet string) (string, string) {
+			return csvPath(sheet), ""
+		},
+	}
+}
+
+func writeReportSheets(writer spreadsheet.Writer, inputData InputData, fsmStore PeopleStore, ctrStore PeopleStore) error {
+	err := WriteAwardList(writer, inputData, fsmStore, fsmAwardsSheet, 1)
+	if err != nil {
+		return err
+	}
+
+	err = WriteAwardList(writer, inputData, ctrStore, ctrAwardsSheet, len(fsmStore.AwardDependents)+1)
+	if err != nil {
+		return err
+	}
+
+	err = WriteEducationReport(writer, inputData, fsmStore, fsmEducationSheet)
+	if err != nil {
+		return err
+	}
+
+	err = WriteEducationReport(writer, inputData, ctrStore, ctrEducationSheet)
+	if err != nil {
+		return err
+	}
+
+	err = WriteDataQuality(writer, inputData)
+	if err != nil {
+		return err
+	}
+
+	return WriteSummary(writer, inputData, fsmStore, ctrStore)
+}
+
+// WriteDataQualityReport writes only the data quality issues to the output folder, for runs stopped
+// before the other reports could be written so the issues can be fixed
+func WriteDataQualityReport(inputData InputData) error {
+	writer := newReportWriter(inputData)
+
+	err := WriteDataQuality(writer, inputData)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// WriteDataQuality writes a sheet with the data quality issues found in the inputs, and what was done about each
+func WriteDataQuality(writer spreadsheet.Writer, inputData InputData) error {
+	headers := []string{"Severity", "Kind", "File", "Sheet", "Row", "Column", "Value", "Claim Number", "Action", "Reason"}
+	err := writer.AddSheet(dataQualitySheet, headers)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range dataIssues(inputData) {
+		claimNumber := spreadsheet.TextCell("")
+		if issue.ClaimNumber != 0 {
+			claimNumber = spreadsheet.TextCell(fmt.Sprintf("%d", issue.ClaimNumber))
+		}
+
+		err = writer.Write([]spreadsheet.Cell{
+			spreadsheet.TextCell(string(issue.Severity)),
+			spreadsheet.TextCell(issue.Kind),
+			spreadsheet.TextCell(issue.Location.Path),
+			spreadsheet.TextCell(issue.Location.Sheet),
+			spreadsheet.NumberCell(float64(issue.Location.Row)),
+			spreadsheet.TextCell(issue.Column),
+			spreadsheet.TextCell(issue.Value),
+			claimNumber,
+			spreadsheet.TextCell(issue.Action),
+			spreadsheet.TextCell(issue.Reason),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteSummary writes a sheet with the number of people in each report and the options they were generated with
+func WriteSummary(writer spreadsheet.Writer, inputData InputData, fsmStore PeopleStore, ctrStore PeopleStore) error {
+	err := writer.AddSheet(summarySheet, []string{"Item", "Value"})
+	if err != nil {
+		return err
+	}
+
+	count := func(n int) spreadsheet.Cell {
+		return spreadsheet.NumberCell(float64(n))
+	}
+
+	yesNo := func(b bool) spreadsheet.Cell {
+		if b {
+			return spreadsheet.TextCell("Yes")
+		}
+		return spreadsheet.TextCell("No")
+	}
+
+	rows := [][]spreadsheet.Cell{
+		{spreadsheet.TextCell("Generated"), spreadsheet.DateCell(time.Now(), "02-01-2006")},
+		{spreadsheet.TextCell("Rollover mode"), yesNo(inputData.rolloverMode)},
+		{spreadsheet.TextCell("Award CG"), yesNo(inputData.awardCG)},
+		{spreadsheet.TextCell("FSM qualifying people"), count(len(fsmStore.People))},
+		{spreadsheet.TextCell(fsmAwardsSheet), count(len(fsmStore.AwardDependents))},
+		{spreadsheet.TextCell(fsmEducationSheet), count(len(fsmStore.ReportForEducationDependents))},
+		{spreadsheet.TextCell("CTR qualifying people"), count(len(ctrStore.People))},
+		{spreadsheet.TextCell(ctrAwardsSheet), count(len(ctrStore.AwardDependents))},
+		{spreadsheet.TextCell(ctrEducationSheet), count(len(ctrStore.ReportForEducationDependents))},
+		{spreadsheet.TextCell("Data quality issues"), count(len(dataIssues(inputData)))},
+	}
+
+	for _, row := range rows {
+		err = writer.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
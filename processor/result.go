@@ -0,0 +1,267 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// Phases of a run that are timed
+const (
+	PhaseInputs  = "inputs"
+	PhaseFsm     = "FSM"
+	PhaseCtr     = "CTR"
+	PhaseReports = "reports"
+	PhaseTotal   = "total"
+)
+
+// InputFile identifies the version of an input a run read, so results can be traced back to their inputs
+type InputFile struct {
+	Input    string    `json:"input"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256,omitempty"`
+	Error    string    `json:"error,omitempty"` // why the file couldn't be fingerprinted
+}
+
+// OutputFile is a file written by a run, for xlsx outputs there's one for each sheet of the workbook
+type OutputFile struct {
+	Path  string `json:"path"`
+	Sheet string `json:"sheet,omitempty"`
+	Rows  int    `json:"rows"` // not including the header row
+}
+
+// Timing is how long a phase of a run took
+type Timing struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// StoreSummary has the counts of the final state of a PeopleStore
+type StoreSummary struct {
+	StoreCounts
+
+	// Qualifiers is the number of people by the benefit or income that qualified them, e.g. "UC"
+	Qualifiers map[string]int `json:"qualifiers"`
+
+	// The number of dependents to award with each entitlement
+	NewFSM      int `json:"new_fsm"`
+	NewCG       int `json:"new_cg"`
+	ExistingFSM int `json:"existing_fsm"`
+	ExistingCG  int `json:"existing_cg"`
+}
+
+// Summary returns the counts of the people and dependents in the store
+func (p PeopleStore) Summary() StoreSummary {
+	summary := StoreSummary{
+		StoreCounts: countStore(p),
+		Qualifiers:  map[string]int{},
+	}
+
+	for _, person := range p.People {
+		if person.QualiferType != "" {
+			summary.Qualifiers[person.QualiferType]++
+		}
+	}
+
+	for _, d := range p.AwardDependents {
+		if d.NewFSM {
+			summary.NewFSM++
+		}
+		if d.NewCG {
+			summary.NewCG++
+		}
+		if d.ExistingFSM {
+			summary.ExistingFSM++
+		}
+		if d.ExistingCG {
+			summary.ExistingCG++
+		}
+	}
+
+	return summary
+}
+
+// fingerprintInputs returns the size, modification time and hash of each input
+func fingerprintInputs(inputData InputData) []InputFile {
+	files := []InputFile{}
+
+	schemas := inputData.schemas()
+	for i, input := range inputData.inputs() {
+		file := InputFile{Input: schemas[i].Name, Path: input.Path}
+
+		err := fingerprintFile(&file)
+		if err != nil {
+			file.Error = err.Error()
+		}
+
+		files = append(files, file)
+	}
+
+	return files
+}
+
+func fingerprintFile(file *InputFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	file.Size = info.Size()
+	file.Modified = info.ModTime()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
+}
+
+// runRecord collects the outputs and timings of a run. It's shared by copies of the
+// run's InputData, is safe for concurrent use, and a nil runRecord records nothing.
+type runRecord struct {
+	mu      sync.Mutex
+	outputs []*OutputFile
+	timings []Timing
+}
+
+// addOutput records a file being written, rows are added to the returned OutputFile with addRows
+func (r *runRecord) addOutput(path string, sheet string) *OutputFile {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	output := &OutputFile{Path: path, Sheet: sheet}
+	r.outputs = append(r.outputs, output)
+	return output
+}
+
+// addRows counts rows written to an output, does nothing if output is nil
+func (r *runRecord) addRows(output *OutputFile, rows int) {
+	if r == nil || output == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	output.Rows += rows
+}
+
+// Outputs returns the files written so far, in the order they were created
+func (r *runRecord) Outputs() []OutputFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outputs := []OutputFile{}
+	for _, output := range r.outputs {
+		outputs = append(outputs, *output)
+	}
+	return outputs
+}
+
+// clearOutputs forgets the files written so far, once they've been deleted
+func (r *runRecord) clearOutputs() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outputs = nil
+}
+
+// time records how long the phase has taken since start
+func (r *runRecord) time(phase string, start time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timings = append(r.timings, Timing{Phase: phase, Duration: time.Since(start)})
+}
+
+// Timings returns the phases timed so far, in the order they finished
+func (r *runRecord) Timings() []Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timings := make([]Timing, len(r.timings))
+	copy(timings, r.timings)
+	return timings
+}
+
+// recordingWriter records the sheets written, and the rows in each, to a run's record,
+// emitting a progress event as each is finished
+type recordingWriter struct {
+	spreadsheet.Writer
+	record       *runRecord
+	progress     *progress
+	pathForSheet func(sheet string) (path string, sheetName string)
+	output       *OutputFile
+	written      *Event // the event of the current sheet, emitted when it's finished
+}
+
+// AddSheet starts a new sheet, recording it as an output
+func (w *recordingWriter) AddSheet(name string, headers []string) error {
+	w.finishSheet()
+
+	err := w.Writer.AddSheet(name, headers)
+	if err != nil {
+		return err
+	}
+
+	path, sheet := w.pathForSheet(name)
+	w.output = w.record.addOutput(path, sheet)
+	w.written = &Event{Event: EventFileWritten, Path: path, Sheet: sheet}
+	return nil
+}
+
+// Write writes a row to the current sheet, counting it
+func (w *recordingWriter) Write(row []spreadsheet.Cell) error {
+	err := w.Writer.Write(row)
+	if err == nil {
+		w.record.addRows(w.output, 1)
+		if w.written != nil {
+			w.written.Rows++
+		}
+	}
+	return err
+}
+
+// Close finishes the last sheet and closes the writer
+func (w *recordingWriter) Close() error {
+	err := w.Writer.Close()
+	if err == nil {
+		w.finishSheet()
+	}
+	return err
+}
+
+// finishSheet emits the progress event of the current sheet
+func (w *recordingWriter) finishSheet() {
+	if w.written != nil {
+		w.progress.emit(*w.written)
+		w.written = nil
+	}
+}
@@ -0,0 +1,572 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/addjam/fsm-processor/llog"
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// headerSearchRows is how many rows at the top of an input are searched for the header row
+const headerSearchRows = 20
+
+// Names of the inputs, used by InputData.Input and as the keys of Config.ColumnAliases
+const (
+	InputBenefitExtract  = "benefit_extract"
+	InputDependentsSHBE  = "dependents_shbe"
+	InputUniversalCredit = "universal_credit"
+	InputAwards          = "awards"
+	InputSchoolRoll      = "school_roll"
+	InputConsent         = "consent"
+	InputFilter          = "filter"
+)
+
+// inputNames are the names of the inputs, in the same order as InputData.inputs
+var inputNames = []string{InputBenefitExtract, InputDependentsSHBE, InputUniversalCredit, InputAwards, InputSchoolRoll, InputConsent, InputFilter}
+
+// Config has the inputs and options of a run. It can be loaded from a file with LoadConfig.
+type Config struct {
+	// Version of the config file format, see ConfigVersion
+	Version int `json:"version" yaml:"version"`
+
+	// Input file paths. Sheet names are only needed for workbooks where the
+	// sheet isn't the first, or the first with the expected headers.
+	BenefitExtract  string `json:"benefit_extract" yaml:"benefit_extract"`
+	DependentsSHBE  string `json:"dependents_shbe" yaml:"dependents_shbe"`
+	DependentsSheet string `json:"dependents_sheet,omitempty" yaml:"dependents_sheet,omitempty"`
+	UniversalCredit string `json:"universal_credit" yaml:"universal_credit"`
+	Awards          string `json:"awards" yaml:"awards"`
+	AwardsSheet     string `json:"awards_sheet,omitempty" yaml:"awards_sheet,omitempty"`
+	SchoolRoll      string `json:"school_roll" yaml:"school_roll"`
+	SchoolRollSheet string `json:"school_roll_sheet,omitempty" yaml:"school_roll_sheet,omitempty"`
+	Consent         string `json:"consent" yaml:"consent"`
+	ConsentSheet    string `json:"consent_sheet,omitempty" yaml:"consent_sheet,omitempty"`
+	Filter          string `json:"filter" yaml:"filter"`
+	FilterSheet     string `json:"filter_sheet,omitempty" yaml:"filter_sheet,omitempty"`
+
+	// ColumnAliases are other labels input columns have been exported with, by input
+	// then column, e.g. {"benefit_extract": {"PostCode": ["Post Code"]}}
+	ColumnAliases map[string]spreadsheet.HeaderAliases `json:"column_aliases,omitempty" yaml:"column_aliases,omitempty"`
+
+	// Options
+	RolloverMode  bool    `json:"rollover" yaml:"rollover"` // when NLC wipes out the data for the previous year and prepares the award for the next school year.
+	AwardCG       bool    `json:"award_cg" yaml:"award_cg"` // e.g. might not awarded after about 20th March
+	BenefitAmount float32 `json:"benefit_amount" yaml:"benefit_amount"`
+	CtcWtcFigure  float32 `json:"ctc_wtc_figure" yaml:"ctc_wtc_figure"`
+	CtcFigure     float32 `json:"ctc_figure" yaml:"ctc_figure"`
+	Lenient       bool    `json:"lenient" yaml:"lenient"`             // skip malformed rows and exclude claims with invalid values rather than failing, they're listed in the Result
+	ValidateOnly  bool    `json:"validate_only" yaml:"validate_only"` // check the inputs against their schemas without generating awards
+
+	// DataIssues decides what happens when a claim has each kind of data quality issue, e.g. {"invalid dob": "exclude"}.
+	// Kinds not given stop the run, or exclude the claim when Lenient.
+	DataIssues map[string]IssuePolicy `json:"data_issues,omitempty" yaml:"data_issues,omitempty"`
+
+	// Rules
+	PensionAllowance     float32  `json:"pension_allowance" yaml:"pension_allowance"`         // weekly pension income allowed before it counts towards the tax credit figure
+	PassportedIndicators []string `json:"passported_indicators" yaml:"passported_indicators"` // passported claim indicators that qualify for FSM
+	ConsentRemoved       string   `json:"consent_removed" yaml:"consent_removed"`             // consent description of people who've removed consent
+
+	// Matching thresholds, scores are from 0 (no similarity) to 1 (identical). Each must be above 0 and up to 1.
+	SchoolMatchThreshold   float64 `json:"school_match_threshold" yaml:"school_match_threshold"`     // weighted score for a dependent to match a school roll pupil
+	NamePrefilterThreshold float64 `json:"name_prefilter_threshold" yaml:"name_prefilter_threshold"` // name score below which a pupil isn't compared any further
+	AwardMatchThreshold    float64 `json:"award_match_threshold" yaml:"award_match_threshold"`       // name score for a dependent to match an existing award
+
+	// Stages of the FSM and CTR algorithms, defaulting to FsmStages() and CtrStages().
+	// Stages can be added, removed or reordered for councils that award differently.
+	FsmStages []Stage `json:"-" yaml:"-"`
+	CtrStages []Stage `json:"-" yaml:"-"`
+
+	// FsmStageNames and CtrStageNames pick the stages to run by name and in order, so a config file can remove
+	// or reorder them, e.g. ["consent", "household", "income"]. Names are looked up in FsmStages and CtrStages.
+	FsmStageNames []string `json:"fsm_stages,omitempty" yaml:"fsm_stages,omitempty"`
+	CtrStageNames []string `json:"ctr_stages,omitempty" yaml:"ctr_stages,omitempty"`
+
+	// Outputs
+	OutputFolder string `json:"output_folder" yaml:"output_folder"`
+	OutputFormat string `json:"output_format" yaml:"output_format"` // csv or xlsx
+	DevMode      bool   `json:"dev" yaml:"dev"`                     // also writes reports of fuzzy matches to the output folder
+
+	// Debug options
+	DebugClaimNumber int        `json:"debug_claim_number" yaml:"debug_claim_number"`
+	LogLevel         llog.Level `json:"log_level" yaml:"log_level"` // debug, info, warn or error
+	Log              io.Writer  `json:"-" yaml:"-"`                 // optional, receives log entries at LogLevel and above as json lines
+
+	// Progress optionally receives events as json lines as the run progresses, see Event
+	Progress io.Writer `json:"-" yaml:"-"`
+}
+
+// Result is the outcome of a run
+type Result struct {
+	// The final state of the FSM and CTR algorithm data, nil if the run stopped before reaching them
+	Fsm *PeopleStore
+	Ctr *PeopleStore
+
+	// The number of people and dependents before and after each stage of the FSM and CTR algorithms
+	FsmFunnel []StageStats
+	CtrFunnel []StageStats
+
+	// Validation has the results of checking each input against its schema when ValidateOnly is set
+	Validation []InputValidation
+
+	// DataQuality lists the problems found with the data in the inputs, and what was done about each
+	DataQuality []DataIssue
+
+	// Inputs identifies the version of each input read, Outputs lists the files written
+	Inputs  []InputFile
+	Outputs []OutputFile
+
+	// Trace has the decisions made about every claim and dependent, and the evidence used
+	Trace *Trace
+
+	// Timings has how long each phase of the run took
+	Timings []Timing
+
+	// Cancelled is true if the run was stopped by its context, the files it had written have been deleted
+	Cancelled bool
+
+	// Log summarises the warnings and errors logged during the run, problems that didn't stop it but may affect its results
+	Log llog.Summary
+
+	// Config is the config the run used, so it can be reproduced
+	Config Config
+}
+
+// InputData represents all options and files received, and the state shared by the stages of a run
+type InputData struct {
+	// Debug options
+	debugClaimNumber int
+
+	// Options
+	rolloverMode  bool
+	awardCG       bool
+	benefitAmount float32
+	ctcWtcFigure  float32
+	ctcFigure     float32
+	outputFolder  string
+	outputFormat  string
+	devMode       bool
+
+	// Rules
+	pensionAllowance     float32
+	passportedIndicators []string
+	consentRemoved       string
+
+	// Matching thresholds
+	schoolMatchThreshold   float64
+	namePrefilterThreshold float64
+	awardMatchThreshold    float64
+
+	// File paths
+	benefitExtract  spreadsheet.ParserInput
+	dependentsSHBE  spreadsheet.ParserInput
+	universalCredit spreadsheet.ParserInput
+	fsmCgAwards     spreadsheet.ParserInput
+	schoolRoll      spreadsheet.ParserInput
+	consent360      spreadsheet.ParserInput
+	filter          spreadsheet.ParserInput
+
+	config Config // the config of the run, for custom stages
+
+	// Stages
+	fsmStages []Stage
+	ctrStages []Stage
+	fsmAwards []Dependent // the FSM award list, left out of the CTR awards
+	algorithm string      // FSM or CTR, for the trace
+	stage     string      // the stage being run, for the trace
+
+	ctx      context.Context // stops the run once it's cancelled or its deadline has passed, optional
+	log      *llog.Logger
+	report   *spreadsheet.Report // rows skipped and cells replaced while reading the inputs
+	record   *runRecord          // outputs and timings
+	quality  *dataQuality        // data quality issues found by the stages
+	progress *progress           // events as the run progresses
+	trace    *Trace              // decisions made about each claim and dependent
+}
+
+// DefaultConfig returns a Config with the default options and no inputs
+func DefaultConfig() Config {
+	return Config{
+		Version: ConfigVersion,
+		ColumnAliases: map[string]spreadsheet.HeaderAliases{
+			// Labels the benefits system has exported benefit extract columns with
+			InputBenefitExtract: {
+				"PostCode": {"Post Code"},
+				"Address1": {"Address 1"},
+				"Address2": {"Address 2"},
+				"Address3": {"Address 3"},
+				"Address4": {"Address 4"},
+				"Address5": {"Address 5"},
+			},
+		},
+		AwardCG:                true,
+		BenefitAmount:          610.0,   // £610
+		CtcWtcFigure:           6420.0,  // £6420
+		CtcFigure:              16105.0, // £16105
+		PensionAllowance:       300.0,   // £300
+		PassportedIndicators:   []string{"ESA(IR)", "Income Support", "JSA(IB)"},
+		ConsentRemoved:         "FSM&CG Consent Removed",
+		SchoolMatchThreshold:   0.95,
+		NamePrefilterThreshold: 0.7,
+		AwardMatchThreshold:    0.95,
+		OutputFolder:           "./",
+		OutputFormat:           csvOutput,
+		DebugClaimNumber:       -1,
+	}
+}
+
+// Run generates the FSM and CTR award lists and reports for education from the inputs in the config,
+// writing them to the output folder. It has no global state so can be called concurrently, though
+// runs writing to the same output folder will overwrite each other's outputs.
+//
+// The Result is returned even when there's an error, with everything found before the run stopped.
+// Once ctx is cancelled or its deadline passes the run stops, deleting the files it's written, and
+// returns ErrCancelled with a Result that's Cancelled.
+func Run(ctx context.Context, config Config) (Result, error) {
+	start := time.Now()
+	inputData := newInputData(config).withContext(ctx)
+	result := Result{Config: config, Trace: inputData.trace}
+
+	finish := func(err error) (Result, error) {
+		if err != nil && ctx.Err() != nil {
+			removeOutputs(inputData)
+			result.Cancelled = true
+			err = ErrCancelled{reason: ctx.Err()}
+		}
+
+		inputData.record.time(PhaseTotal, start)
+		if err != nil {
+			inputData.log.Errorf("%s", err)
+		}
+
+		result.DataQuality = dataIssues(inputData)
+		result.Outputs = inputData.record.Outputs()
+		result.Timings = inputData.record.Timings()
+		result.Log = inputData.log.Summary()
+		return result, err
+	}
+
+	err := config.check()
+	if err != nil {
+		return finish(err)
+	}
+
+	phaseStart := time.Now()
+	result.Inputs = fingerprintInputs(inputData)
+	inputData.record.time(PhaseInputs, phaseStart)
+
+	if config.ValidateOnly {
+		result.Validation = ValidateInputs(inputData)
+		return finish(ctx.Err())
+	}
+
+	inputData.log.Infof("Rollover? %t", inputData.rolloverMode)
+
+	phaseStart = time.Now()
+	fsmStore, fsmFunnel, err := GenerateFsmAwards(inputData)
+	inputData.record.time(PhaseFsm, phaseStart)
+	result.Fsm = &fsmStore
+	result.FsmFunnel = fsmFunnel
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return finish(stopped(inputData, err))
+	}
+
+	phaseStart = time.Now()
+	ctrStore, ctrFunnel, err := GenerateCtrBasedAwards(inputData, fsmStore)
+	inputData.record.time(PhaseCtr, phaseStart)
+	result.Ctr = &ctrStore
+	result.CtrFunnel = ctrFunnel
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return finish(stopped(inputData, err))
+	}
+
+	phaseStart = time.Now()
+	err = WriteReports(inputData, fsmStore, ctrStore)
+	// The trace is written even if the reports can't be, to explain the run
+	traceErr := writeTrace(inputData, path.Join(inputData.outputFolder, traceFile))
+	if err == nil {
+		err = traceErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	inputData.record.time(PhaseReports, phaseStart)
+	return finish(err)
+}
+
+// stopped writes the data quality report and trace of a run stopped by err, so the issues found before it stopped
+// can be fixed and the decisions made up to then explained. Nothing is written for a cancelled run.
+func stopped(inputData InputData, err error) error {
+	if inputData.cancelled() != nil {
+		return err
+	}
+
+	writeErr := WriteDataQualityReport(inputData)
+	if writeErr != nil {
+		inputData.log.Errorf("Can't write the data quality report: %s", writeErr)
+	}
+
+	writeErr = writeTrace(inputData, path.Join(inputData.outputFolder, traceFile))
+	if writeErr != nil {
+		inputData.log.Errorf("Can't write the trace: %s", writeErr)
+	}
+
+	return err
+}
+
+// removeOutputs deletes the files a cancelled run has written, as they're incomplete
+func removeOutputs(inputData InputData) {
+	removed := map[string]bool{}
+	for _, output := range inputData.record.Outputs() {
+		if removed[output.Path] {
+			continue
+		}
+		removed[output.Path] = true
+
+		err := os.Remove(output.Path)
+		if err != nil && !os.IsNotExist(err) {
+			inputData.log.With(llog.Fields{"file": output.Path}).Warnf("Can't delete the output of the cancelled run: %s", err)
+		}
+	}
+
+	inputData.record.clearOutputs()
+}
+
+// check returns an error if an input is missing or an option is invalid
+func (c Config) check() error {
+	inputs := []struct{ name, path string }{
+		{"benefit extract", c.BenefitExtract},
+		{"dependents SHBE", c.DependentsSHBE},
+		{"universal credit", c.UniversalCredit},
+		{"awards", c.Awards},
+		{"school roll", c.SchoolRoll},
+		{"consent", c.Consent},
+		{"filter", c.Filter},
+	}
+	for _, input := range inputs {
+		if input.path == "" {
+			return ErrMissingInput{input: input.name}
+		}
+	}
+
+	if c.OutputFormat != csvOutput && c.OutputFormat != xlsxOutput {
+		return ErrInvalidOutputFormat{format: c.OutputFormat}
+	}
+
+	thresholds := []struct {
+		name  string
+		value float64
+	}{
+		{"school_match_threshold", c.SchoolMatchThreshold},
+		{"name_prefilter_threshold", c.NamePrefilterThreshold},
+		{"award_match_threshold", c.AwardMatchThreshold},
+	}
+	for _, threshold := range thresholds {
+		// A threshold of 0 would match everyone
+		if !(threshold.value > 0 && threshold.value <= 1) {
+			return ErrInvalidThreshold{name: threshold.name, value: threshold.value}
+		}
+	}
+
+	for kind, policy := range c.DataIssues {
+		if !isClaimIssueKind(kind) {
+			return ErrUnknownIssueKind{kind: kind}
+		}
+		if policy != AbortRun && policy != ExcludeClaim {
+			return ErrInvalidIssuePolicy{policy: string(policy)}
+		}
+	}
+
+	_, err := stagesNamed(PhaseFsm, c.fsmStages(), c.FsmStageNames)
+	if err != nil {
+		return err
+	}
+
+	_, err = stagesNamed(PhaseCtr, c.ctrStages(), c.CtrStageNames)
+	return err
+}
+
+// fsmStages returns the stages of the FSM algorithm that names are looked up in
+func (c Config) fsmStages() []Stage {
+	if c.FsmStages == nil {
+		return FsmStages()
+	}
+	return c.FsmStages
+}
+
+// ctrStages returns the stages of the CTR algorithm that names are looked up in
+func (c Config) ctrStages() []Stage {
+	if c.CtrStages == nil {
+		return CtrStages()
+	}
+	return c.CtrStages
+}
+
+// newInputData creates the InputData of a run, each run has its own log, report and registry
+func newInputData(config Config) InputData {
+	inputData := InputData{
+		debugClaimNumber: config.DebugClaimNumber,
+
+		rolloverMode:  config.RolloverMode,
+		awardCG:       config.AwardCG,
+		benefitAmount: config.BenefitAmount,
+		ctcWtcFigure:  config.CtcWtcFigure,
+		ctcFigure:     config.CtcFigure,
+		outputFolder:  config.OutputFolder,
+		outputFormat:  config.OutputFormat,
+		devMode:       config.DevMode,
+
+		pensionAllowance:     config.PensionAllowance,
+		passportedIndicators: config.PassportedIndicators,
+		consentRemoved:       config.ConsentRemoved,
+
+		schoolMatchThreshold:   config.SchoolMatchThreshold,
+		namePrefilterThreshold: config.NamePrefilterThreshold,
+		awardMatchThreshold:    config.AwardMatchThreshold,
+
+		benefitExtract: spreadsheet.ParserInput{
+			Path:             config.BenefitExtract,
+			HasHeaders:       true,
+			DetectHeaderRows: headerSearchRows,
+			HeaderAliases:    config.ColumnAliases[InputBenefitExtract],
+			RequiredHeaders:  benefitExtractSchema.RequiredHeaders(),
+		},
+		dependentsSHBE: spreadsheet.ParserInput{
+			Path:          config.DependentsSHBE,
+			HasHeaders:    true,
+			HeaderAliases: config.ColumnAliases[InputDependentsSHBE],
+			SheetName:     config.DependentsSheet,
+		},
+		universalCredit: spreadsheet.ParserInput{
+			Path:   config.UniversalCredit,
+			Layout: universalCreditLayout,
+		},
+		fsmCgAwards: spreadsheet.ParserInput{
+			Path:             config.Awards,
+			HasHeaders:       true,
+			DetectHeaderRows: headerSearchRows,
+			HeaderAliases:    config.ColumnAliases[InputAwards],
+			SheetName:        config.AwardsSheet,
+			Stream:           true,
+			RequiredHeaders:  fsmCgAwardsSchema.RequiredHeaders(),
+		},
+		schoolRoll: spreadsheet.ParserInput{
+			Path:            config.SchoolRoll,
+			HasHeaders:      true,
+			HeaderAliases:   config.ColumnAliases[InputSchoolRoll],
+			SheetName:       config.SchoolRollSheet,
+			Stream:          true,
+			RequiredHeaders: schoolRollSchema.RequiredHeaders(),
+		},
+		consent360: spreadsheet.ParserInput{
+			Path:               config.Consent,
+			HasHeaders:         true,
+			DetectHeaderRows:   headerSearchRows,
+			HeaderAliases:      config.ColumnAliases[InputConsent],
+			SheetName:          config.ConsentSheet,
+			FindSheetByHeaders: config.ConsentSheet == "",
+			RequiredHeaders:    consent360Schema.RequiredHeaders(),
+		},
+		filter: spreadsheet.ParserInput{
+			Path:               config.Filter,
+			HasHeaders:         true,
+			DetectHeaderRows:   headerSearchRows,
+			HeaderAliases:      config.ColumnAliases[InputFilter],
+			SheetName:          config.FilterSheet,
+			FindSheetByHeaders: config.FilterSheet == "",
+			RequiredHeaders:    filterSchema.RequiredHeaders(),
+		},
+
+		config: config,
+
+		log:    llog.New(config.Log, config.LogLevel),
+		report: &spreadsheet.Report{},
+		record: &runRecord{},
+		trace:  &Trace{},
+
+		progress: newProgress(config.Progress),
+	}
+
+	// Unknown stage names are returned as an error by Config.check
+	inputData.fsmStages, _ = stagesNamed(PhaseFsm, config.fsmStages(), config.FsmStageNames)
+	inputData.ctrStages, _ = stagesNamed(PhaseCtr, config.ctrStages(), config.CtrStageNames)
+
+	policy := spreadsheet.Strict
+	issuePolicy := AbortRun
+	if config.Lenient {
+		policy = spreadsheet.Lenient
+		issuePolicy = ExcludeClaim
+	}
+	inputData.quality = newDataQuality(config.DataIssues, issuePolicy)
+
+	registry := spreadsheet.NewRegistry()
+	schemas := inputData.schemas()
+	for i, input := range inputData.inputs() {
+		input.Policy = policy
+		input.Report = inputData.report
+		input.Registry = registry
+		input.Progress = inputProgress(inputData.progress, schemas[i].Name, input.Path)
+	}
+
+	return inputData
+}
+
+// withContext returns the InputData with the context of the run, which also stops the inputs being parsed
+func (i InputData) withContext(ctx context.Context) InputData {
+	i.ctx = ctx
+	for _, input := range i.inputs() {
+		input.Context = ctx
+	}
+	return i
+}
+
+// cancelled returns the error of the run's context once it's cancelled or its deadline has passed
+func (i InputData) cancelled() error {
+	if i.ctx == nil {
+		return nil
+	}
+	return i.ctx.Err()
+}
+
+// done returns a channel that's closed when the run's context is done, it's never closed without a context
+func (i InputData) done() <-chan struct{} {
+	if i.ctx == nil {
+		return nil
+	}
+	return i.ctx.Done()
+}
+
+// inputs returns all of the input files
+func (i *InputData) inputs() []*spreadsheet.ParserInput {
+	return []*spreadsheet.ParserInput{
+		&i.benefitExtract,
+		&i.dependentsSHBE,
+		&i.universalCredit,
+		&i.fsmCgAwards,
+		&i.schoolRoll,
+		&i.consent360,
+		&i.filter,
+	}
+}
+
+// schemas returns the schema of each input, in the same order as inputs
+func (i *InputData) schemas() []spreadsheet.Schema {
+	return []spreadsheet.Schema{
+		withPassportedIndicators(benefitExtractSchema, i.passportedIndicators),
+		dependentsSHBESchema,
+		universalCreditSchema,
+		fsmCgAwardsSchema,
+		schoolRollSchema,
+		consent360Schema,
+		filterSchema,
+	}
+}
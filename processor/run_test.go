@@ -0,0 +1,395 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// testConfig returns the config of a run of the test inputs, with four claims:
+//   - 1001 consents and is passported, Ann is on the school roll and Dan isn't, and it receives CTS
+//   - 1002 consents and qualifies on UC, Bob already has FSM and CG and Gus is in nursery
+//   - 1003 removed consent and receives CTS, Eve is in the exclusion list
+//   - 1004 consents but earns too much
+func testConfig(t *testing.T) Config {
+	config := DefaultConfig()
+	config.OutputFolder = t.TempDir()
+	config.BenefitExtract = "./testdata/Benefit Extract.csv"
+	config.DependentsSHBE = "./testdata/dependants SHBE.csv"
+	config.UniversalCredit = "./testdata/hb-uc.d.txt"
+	config.Awards = "./testdata/Current Year Awards.csv"
+	config.SchoolRoll = "./testdata/School Roll.csv"
+	config.Consent = "./testdata/Consent Report.csv"
+	config.Filter = "./testdata/Filter File.csv"
+	return config
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Returns an error for a missing input", func(t *testing.T) {
+		config := testConfig(t)
+		config.SchoolRoll = ""
+
+		_, err := Run(context.Background(), config)
+
+		if _, ok := err.(ErrMissingInput); !ok {
+			t.Fatalf("Expected ErrMissingInput but got %#v", err)
+		}
+	})
+
+	t.Run("Returns an error for a threshold that isn't a score", func(t *testing.T) {
+		for _, threshold := range []float64{0, -0.5, 1.5} {
+			config := testConfig(t)
+			config.SchoolMatchThreshold = threshold
+
+			_, err := Run(context.Background(), config)
+
+			if _, ok := err.(ErrInvalidThreshold); !ok {
+				t.Fatalf("Expected ErrInvalidThreshold for %g but got %#v", threshold, err)
+			}
+		}
+	})
+
+	t.Run("Returns a cancelled result once the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := Run(ctx, testConfig(t))
+
+		if _, ok := err.(ErrCancelled); !ok || !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected ErrCancelled but got %#v", err)
+		}
+		if !result.Cancelled || len(result.FsmFunnel) != 0 {
+			t.Errorf("Expected a cancelled result before any stages ran but got %#v", result)
+		}
+	})
+
+	t.Run("Returns a cancelled result once the deadline has passed", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+
+		_, err := Run(ctx, testConfig(t))
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected the deadline to be exceeded but got %#v", err)
+		}
+	})
+
+	t.Run("Returns the result so far with an error", func(t *testing.T) {
+		// This benefit extract is missing income columns
+		var log bytes.Buffer
+		config := testConfig(t)
+		config.BenefitExtract = "./testdata/Benefit Extract_06_09_19.txt"
+		config.Log = &log
+
+		result, err := Run(context.Background(), config)
+
+		if err == nil {
+			t.Fatalf("Expected an error")
+		}
+		if result.Fsm == nil || result.Ctr != nil {
+			t.Errorf("Expected only the FSM store but got %#v and %#v", result.Fsm, result.Ctr)
+		}
+		if !strings.Contains(log.String(), `"msg":"Rollover? false"`) {
+			t.Errorf("Expected the log of the run but got %s", log.String())
+		}
+		if result.Log.Errors != 1 || result.Log.Entries[0].Message != err.Error() {
+			t.Errorf("Expected the error in the log summary but got %#v", result.Log)
+		}
+		if _, err := ReadTrace(filepath.Join(config.OutputFolder, traceFile)); err != nil {
+			t.Errorf("Expected the trace to be written but got %#v", err)
+		}
+	})
+
+	t.Run("Generates the award lists and reports for education", func(t *testing.T) {
+		config := testConfig(t)
+
+		result, err := Run(context.Background(), config)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		expected := map[string][]string{
+			"report_awards_fsm.csv":    {"1,5001,1001,AB100001A,Ms,Jane,Smith,", ",Ann,Smith,14-03-2017,", ",PASSPORTED,3. Award FSM and CG,"},
+			"report_awards_ctr.csv":    {",5004,1003,AB100003A,Ms,Lisa,Brown,", ",Cara,Brown,05-05-2016,", ",1. Award CG + request consent,"},
+			"report_education_fsm.csv": {"1001,Dan,Smith,01-06-2018"},
+			"report_education_ctr.csv": {"1001,Dan,Smith,01-06-2018"},
+		}
+		for file, rows := range expected {
+			contents := readOutput(t, config, file)
+			if lines := strings.Count(contents, "\n"); lines != 2 {
+				t.Errorf("Expected a header and 1 row in %s but got %s", file, contents)
+			}
+			for _, row := range rows {
+				if !strings.Contains(contents, row) {
+					t.Errorf("Expected %s in %s but got %s", row, file, contents)
+				}
+			}
+		}
+		if len(result.DataQuality) != 0 || result.Log.Warnings != 0 || result.Log.Errors != 0 {
+			t.Errorf("Expected no data quality issues or problems logged but got %#v, %#v", result.DataQuality, result.Log)
+		}
+	})
+
+	t.Run("Records the people and dependents left by each stage", func(t *testing.T) {
+		result, err := Run(context.Background(), testConfig(t))
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		expectedFsm := []StageStats{
+			{Stage: StageConsent, After: StoreCounts{People: 3}},
+			{Stage: StageHousehold, After: StoreCounts{People: 3}},
+			{Stage: StageIncome, After: StoreCounts{People: 2}},
+			{Stage: StageSchoolMatch, After: StoreCounts{People: 2, AwardDependents: 3, EducationDependents: 1}},
+			{Stage: StageExistingGrants, After: StoreCounts{People: 2, AwardDependents: 3, EducationDependents: 1}},
+			{Stage: StageNewEntitlements, After: StoreCounts{People: 2, AwardDependents: 2, EducationDependents: 1}},
+			{Stage: StageMinimumP1, After: StoreCounts{People: 2, AwardDependents: 1, EducationDependents: 1}},
+			{Stage: StageExclusionList, After: StoreCounts{People: 2, AwardDependents: 1, EducationDependents: 1}},
+		}
+		expectedCtr := []StageStats{
+			{Stage: StageCtr, After: StoreCounts{People: 2}},
+			{Stage: StageHousehold, After: StoreCounts{People: 2}},
+			{Stage: StageCGEligible, After: StoreCounts{People: 2}},
+			{Stage: StageSchoolMatch, After: StoreCounts{People: 2, AwardDependents: 3, EducationDependents: 1}},
+			{Stage: StageExistingGrants, After: StoreCounts{People: 2, AwardDependents: 3, EducationDependents: 1}},
+			{Stage: StageNotReceivingCG, After: StoreCounts{People: 2, AwardDependents: 3, EducationDependents: 1}},
+			{Stage: StageExclusionList, After: StoreCounts{People: 2, AwardDependents: 2, EducationDependents: 1}},
+			{Stage: StageFsmAwards, After: StoreCounts{People: 2, AwardDependents: 1, EducationDependents: 1}},
+			{Stage: StageMinimumP1, After: StoreCounts{People: 2, AwardDependents: 1, EducationDependents: 1}},
+		}
+		assertFunnel(t, result.FsmFunnel, expectedFsm)
+		assertFunnel(t, result.CtrFunnel, expectedCtr)
+	})
+
+	t.Run("Parses each input once, however many stages read it", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		config := testConfig(t)
+		config.Progress = buffer
+
+		_, err := Run(context.Background(), config)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		parses := map[string]int{}
+		for _, event := range readEvents(t, buffer) {
+			if event.Event == EventInputParsed {
+				parses[event.Input]++
+			}
+		}
+		if len(parses) != 7 {
+			t.Errorf("Expected each of the 7 inputs to be parsed but got %v", parses)
+		}
+		for input, count := range parses {
+			if count != 1 {
+				t.Errorf("Expected the %s to be parsed once but got %d", input, count)
+			}
+		}
+	})
+
+	t.Run("Concurrent runs into separate folders have the same results", func(t *testing.T) {
+		var wg sync.WaitGroup
+		configs := make([]Config, 4)
+		results := make([]Result, len(configs))
+		errs := make([]error, len(configs))
+		logs := make([]bytes.Buffer, len(configs))
+		for i := range configs {
+			configs[i] = testConfig(t)
+			configs[i].Log = &logs[i]
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = Run(context.Background(), configs[i])
+			}(i)
+		}
+		wg.Wait()
+
+		files := []string{"report_awards_fsm.csv", "report_awards_ctr.csv", "report_education_fsm.csv", "report_education_ctr.csv", "report_summary.csv"}
+		for i := range configs {
+			if errs[i] != nil {
+				t.Fatalf("Got an unexpected error %#v", errs[i])
+			}
+			if strings.Count(logs[i].String(), "Rollover?") != 1 {
+				t.Errorf("Expected a single run in the log but got %s", logs[i].String())
+			}
+			assertFunnel(t, results[i].FsmFunnel, results[0].FsmFunnel)
+			assertFunnel(t, results[i].CtrFunnel, results[0].CtrFunnel)
+			for _, file := range files {
+				if contents := readOutput(t, configs[i], file); contents != readOutput(t, configs[0], file) {
+					t.Errorf("Expected %s to be the same for each run but got %s", file, contents)
+				}
+			}
+		}
+	})
+
+	t.Run("Validates without generating awards", func(t *testing.T) {
+		config := testConfig(t)
+		config.ValidateOnly = true
+
+		result, err := Run(context.Background(), config)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(result.Validation) != 7 || result.Fsm != nil {
+			t.Errorf("Expected validation of 7 inputs only but got %#v", result)
+		}
+	})
+
+	t.Run("Fingerprints the inputs and times the run", func(t *testing.T) {
+		config := testConfig(t)
+		config.ValidateOnly = true
+		config.Filter = "./testdata/missing.xlsx"
+
+		result, err := Run(context.Background(), config)
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(result.Inputs) != 7 {
+			t.Fatalf("Expected 7 inputs but got %#v", result.Inputs)
+		}
+		if input := result.Inputs[0]; input.Size == 0 || len(input.SHA256) != 64 || input.Error != "" {
+			t.Errorf("Expected the benefit extract to be fingerprinted but got %#v", input)
+		}
+		if input := result.Inputs[6]; input.Error == "" || input.SHA256 != "" {
+			t.Errorf("Expected an error fingerprinting the missing filter but got %#v", input)
+		}
+		if len(result.Timings) != 2 || result.Timings[0].Phase != PhaseInputs || result.Timings[1].Phase != PhaseTotal {
+			t.Errorf("Expected timings of the inputs and total but got %#v", result.Timings)
+		}
+	})
+}
+
+// readOutput returns the contents of a file written to the output folder of the run
+func readOutput(t *testing.T, config Config, file string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(config.OutputFolder, file))
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	return string(contents)
+}
+
+// assertFunnel checks the stages of the funnel and the counts after each, durations aren't compared
+func assertFunnel(t *testing.T, funnel []StageStats, expected []StageStats) {
+	if len(funnel) != len(expected) {
+		t.Fatalf("Expected %d stages but got %#v", len(expected), funnel)
+	}
+	for i := range expected {
+		if funnel[i].Stage != expected[i].Stage || funnel[i].After != expected[i].After {
+			t.Errorf("Expected %#v but got %#v", expected[i], funnel[i])
+		}
+	}
+}
+
+func TestWriteReports(t *testing.T) {
+	// The benefit extract test data is missing income columns, which aren't needed in the reports
+	input := newInputData(testConfig(t)).benefitExtract
+	input.RequiredHeaders = nil
+	parser, err := spreadsheet.NewParser(input)
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	row, err := parser.Next()
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	parser.Close()
+
+	dependent := Dependent{NewFSM: true, Person: Person{BenefitExtractRow: row}, SchoolRollRow: row}
+	store := PeopleStore{AwardDependents: []Dependent{dependent, dependent}}
+
+	t.Run("Records each csv file written", func(t *testing.T) {
+		inputData := newInputData(testConfig(t))
+		inputData.outputFolder = t.TempDir()
+
+		err := WriteReports(inputData, store, PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		outputs := inputData.record.Outputs()
+		if len(outputs) != 6 {
+			t.Fatalf("Expected 6 csv files but got %#v", outputs)
+		}
+		if outputs[0].Path != filepath.Join(inputData.outputFolder, "report_awards_fsm.csv") || outputs[0].Rows != 2 || outputs[0].Sheet != "" {
+			t.Errorf("Expected the FSM award list with 2 rows but got %#v", outputs[0])
+		}
+	})
+
+	t.Run("Records each sheet of the workbook written", func(t *testing.T) {
+		inputData := newInputData(testConfig(t))
+		inputData.outputFolder = t.TempDir()
+		inputData.outputFormat = xlsxOutput
+
+		err := WriteReports(inputData, store, PeopleStore{})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		outputs := inputData.record.Outputs()
+		if len(outputs) != 6 {
+			t.Fatalf("Expected 6 sheets but got %#v", outputs)
+		}
+		for _, output := range outputs {
+			if output.Path != filepath.Join(inputData.outputFolder, reportWorkbook) {
+				t.Errorf("Expected the sheet to be in the workbook but got %#v", output)
+			}
+		}
+		if outputs[1].Sheet != ctrAwardsSheet || outputs[1].Rows != 0 {
+			t.Errorf("Expected the empty CTR award list but got %#v", outputs[1])
+		}
+	})
+}
+
+func TestRemoveOutputs(t *testing.T) {
+	inputData := newInputData(testConfig(t))
+
+	err := WriteDataQualityReport(inputData)
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+
+	removeOutputs(inputData)
+
+	files, err := ioutil.ReadDir(inputData.outputFolder)
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected the outputs to be deleted but found %d files", len(files))
+	}
+	if outputs := inputData.record.Outputs(); len(outputs) != 0 {
+		t.Errorf("Expected no outputs but got %#v", outputs)
+	}
+}
+
+func TestStoreSummary(t *testing.T) {
+	store := PeopleStore{
+		People: []Person{{QualiferType: "UC"}, {QualiferType: "UC"}, {QualiferType: "CTC"}, {}},
+		AwardDependents: []Dependent{
+			{NewFSM: true, NewCG: true},
+			{NewCG: true, ExistingFSM: true},
+			{ExistingCG: true},
+		},
+	}
+
+	summary := store.Summary()
+
+	if summary.People != 4 || summary.AwardDependents != 3 {
+		t.Errorf("Expected the store counts but got %#v", summary.StoreCounts)
+	}
+	if len(summary.Qualifiers) != 2 || summary.Qualifiers["UC"] != 2 || summary.Qualifiers["CTC"] != 1 {
+		t.Errorf("Expected 2 UC and 1 CTC qualifiers but got %#v", summary.Qualifiers)
+	}
+	if summary.NewFSM != 1 || summary.NewCG != 2 || summary.ExistingFSM != 1 || summary.ExistingCG != 1 {
+		t.Errorf("Expected the entitlement counts but got %#v", summary)
+	}
+}
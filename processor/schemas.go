@@ -0,0 +1,168 @@
+package processor
+
+import "github.com/addjam/fsm-processor/spreadsheet"
+
+// Schemas of the inputs. Columns the awards can be generated without, or that only some
+// exports have, are Optional so runs aren't rejected for missing them.
+
+// incomeColumns are the benefit extract columns summed for the tax credit income steps
+var incomeColumns = []string{
+	// Tax credit step one
+	"Clmt Personal Pension",
+	"Clmt State Retirement Pension (incl SERP's graduated pension etc)",
+	"Ptnr Personal Pension",
+	"Ptnr State Retirement Pension (incl SERP's graduated pension etc)",
+	"Clmt Occupational Pension",
+	"Ptnr Occupational Pension",
+
+	// Tax credit step two
+	"Clmt AIF",
+	"Clmt Employment (gross)",
+	"Clmt Self-employment (gross)",
+	"Clmt Student Grant/Loan",
+	"Clmt Sub-tenants",
+	"Clmt Boarders",
+	"Clmt Government Training",
+	"Clmt Statutory Sick Pay",
+	"Clmt Widowed Parent's Allowance",
+	"Clmt Apprenticeship",
+	"Other weekly Income including In-Work Credit",
+	"Ptnr AIF",
+	"Ptnr Employment (gross)",
+	"Ptnr Self-employment (gross)",
+	"Ptnr Student Grant/Loan",
+	"Ptnr Sub-tenants",
+	"Ptnr Boarders",
+	"Ptnr Training for Work/Community Action",
+	"Ptnr New Deal 50+ Employment Credit",
+	"Ptnr Government Training",
+	"Ptnr Carer's Allowance",
+	"Ptnr Statutory Sick Pay",
+	"Ptnr Widowed Parent's Allowance",
+	"Ptnr Apprenticeship",
+	"Clmt Savings Credit",
+	"Ptnr Savings Credit",
+	"Clmt Widows Benefit",
+	"Ptnr Widows Benefit",
+}
+
+// standardClaimIndicator is the passported / standard claim indicator of claims that aren't passported
+const standardClaimIndicator = "Standard"
+
+var benefitExtractSchema = spreadsheet.Schema{
+	Name: "Benefit Extract",
+	Columns: append([]spreadsheet.Column{
+		{Name: "Claim Number", Type: spreadsheet.FieldInt, Required: true, Unique: true},
+		{Name: "Clmt First Forename"},
+		{Name: "Clmt Surname"},
+		{Name: "Clmt Title", Optional: true},
+		{Name: "NINO", Optional: true},
+		{Name: "Ptnr NINO", Optional: true},
+		{Name: "Ptnr First Forename", Optional: true},
+		{Name: "Ptnr Surname", Optional: true},
+		{Name: "Address1", Optional: true},
+		{Name: "Address2", Optional: true},
+		{Name: "Address3", Optional: true},
+		{Name: "Address4", Optional: true},
+		{Name: "Address5", Optional: true},
+		{Name: "PostCode", Optional: true},
+		{Name: "Weekly CTS entitlement", Type: spreadsheet.FieldMoney, Optional: true},
+		{Name: "Clmt Working Tax Credits", Type: spreadsheet.FieldMoney, Optional: true},
+		{Name: "Ptnr Working Tax Credits", Type: spreadsheet.FieldMoney, Optional: true},
+		{Name: "Child tax credit - Claimant", Type: spreadsheet.FieldMoney, Optional: true},
+		{Name: "Child tax credit - Partner", Type: spreadsheet.FieldMoney, Optional: true},
+		{
+			Name:     "Passported / Standard claim indicator",
+			Optional: true,
+			Allowed:  []string{"ESA(IR)", "Income Support", "JSA(IB)", standardClaimIndicator},
+		},
+	}, moneyColumns(incomeColumns)...),
+}
+
+var dependentsSHBESchema = spreadsheet.Schema{
+	Name: "Dependents SHBE",
+	Columns: []spreadsheet.Column{
+		{Name: "Claim Number", Position: 1, Type: spreadsheet.FieldInt},
+		{Name: "Surname", Position: 3},
+		{Name: "Forename", Position: 4},
+		{Name: "Date of Birth", Position: 5, Type: spreadsheet.FieldDate, DateLayouts: []string{"01-02-06", "2006-01-02"}},
+		{Name: "Age", Position: 6, Type: spreadsheet.FieldInt},
+	},
+}
+
+var universalCreditSchema = spreadsheet.Schema{
+	Name: "Universal Credit",
+	Columns: []spreadsheet.Column{
+		{Name: "Claim Number", Type: spreadsheet.FieldInt, Required: true},
+		{Name: "Sequence Number", Type: spreadsheet.FieldInt, Required: true},
+		{Name: "Benefit Amount", Type: spreadsheet.FieldMoney},
+	},
+}
+
+var fsmCgAwardsSchema = spreadsheet.Schema{
+	Name: "FSM & CG Awards",
+	Columns: []spreadsheet.Column{
+		{Name: "NI Number"},
+		{Name: "Pupil Forename"},
+		{Name: "Pupil Surname"},
+		{Name: "FSM Approved"},
+		{Name: "Payrun Date"},
+	},
+}
+
+var schoolRollSchema = spreadsheet.Schema{
+	Name: "School Roll",
+	Columns: []spreadsheet.Column{
+		{Name: "SEEMIS reference", Unique: true},
+		{Name: "Forename"},
+		{Name: "Surname"},
+		{Name: "Date of Birth", Type: spreadsheet.FieldDate, Required: true, DateLayouts: []string{"2-Jan-06", "2-Jan-2006", "2006-01-02"}},
+		{Name: "Pupil's postcode"},
+		{Name: "Pupil's street"},
+		{Name: "Pupil's property", Optional: true},
+		{Name: "Pupil's town", Optional: true},
+		{Name: "School Name", Optional: true},
+		{Name: "Year/Stage", Optional: true},
+	},
+}
+
+var consent360Schema = spreadsheet.Schema{
+	Name: "Consent",
+	Columns: []spreadsheet.Column{
+		{Name: "DocDesc"},
+		{Name: "DocDate"},
+		{Name: "CLAIMREFERENCE", Required: true},
+	},
+}
+
+var filterSchema = spreadsheet.Schema{
+	Name: "Filter",
+	Columns: []spreadsheet.Column{
+		{Name: "claim ref"},
+		{Name: "seemis ID"},
+	},
+}
+
+// moneyColumns returns money columns with the given names
+func moneyColumns(names []string) []spreadsheet.Column {
+	columns := []spreadsheet.Column{}
+	for _, name := range names {
+		columns = append(columns, spreadsheet.Column{Name: name, Type: spreadsheet.FieldMoney})
+	}
+	return columns
+}
+
+// withPassportedIndicators returns the benefit extract schema allowing the passported indicators, and standard claims
+func withPassportedIndicators(schema spreadsheet.Schema, indicators []string) spreadsheet.Schema {
+	columns := make([]spreadsheet.Column, len(schema.Columns))
+	copy(columns, schema.Columns)
+
+	for i, column := range columns {
+		if column.Name == "Passported / Standard claim indicator" {
+			columns[i].Allowed = append(append([]string{}, indicators...), standardClaimIndicator)
+		}
+	}
+
+	schema.Columns = columns
+	return schema
+}
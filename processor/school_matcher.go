@@ -1,11 +1,13 @@
-package main
+package processor
 
 import (
 	"encoding/csv"
 	"fmt"
 	"math"
 	"os"
+	"path"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,14 +15,10 @@ import (
 	"github.com/addjam/fsm-processor/spreadsheet"
 )
 
-const definiteMatchThreshold = 0.95
-
-var numComparisons = 0
-
 // PeopleWithChildrenAtNlcSchool returns just the people from the store
 // that are likely matches for people in the school roll
 func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matched []Dependent, unmatched []Dependent, err error) {
-	schoolRollRows, postcodeIndex, surnameIndex, err := cacheSchoolRoll(inputData.schoolRoll, store)
+	schoolRollRows, postcodeIndex, surnameIndex, err := cacheSchoolRoll(inputData, store)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -37,7 +35,7 @@ func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matc
 			wg.Add(1)
 			rowsWithSurname := surnameIndex[dependent.Surname]
 			allDependents = append(allDependents, dependent.Dependent)
-			go checkSchoolRoll(&wg, matchChannel, dependent, [][]SchoolRollRow{rowsInPostcode, rowsWithSurname, schoolRollRows})
+			go checkSchoolRoll(inputData, &wg, matchChannel, dependent, [][]SchoolRollRow{rowsInPostcode, rowsWithSurname, schoolRollRows})
 		}
 	}
 
@@ -47,10 +45,14 @@ func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matc
 	}()
 
 	var writer *csv.Writer
+	var output *OutputFile
 	if inputData.devMode {
-		file, err := os.Create("report_fuzzy_matches.csv")
+		filePath := path.Join(inputData.outputFolder, "report_fuzzy_matches.csv")
+		file, err := os.Create(filePath)
 		if err != nil {
-			llog.Println("Error creating output")
+			inputData.log.With(llog.Fields{"file": filePath}).Warnf("Couldn't create the fuzzy matches report: %s", err)
+		} else {
+			output = inputData.record.addOutput(filePath, "")
 		}
 		defer file.Close()
 
@@ -66,8 +68,16 @@ func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matc
 
 	matchedDependents := []Dependent{}
 	unmatchedDependents := []Dependent{}
+	numComparisons := 0
+	numMatches := 0
 	for match := range matchChannel {
-		isMatch := match.Score >= definiteMatchThreshold
+		numComparisons += match.Comparisons
+		numMatches++
+		if numMatches%matchProgressInterval == 0 || numMatches == len(allDependents) {
+			inputData.stageEvent(Event{Event: EventMatchesProgress, Done: numMatches, Total: len(allDependents)})
+		}
+
+		isMatch := match.Score >= inputData.schoolMatchThreshold
 		dependent := match.ComparableDependent.Dependent
 		if isMatch {
 			dependent.SeemisForename = spreadsheet.ColByName(match.Row.OriginalRow, "Forename")
@@ -81,6 +91,8 @@ func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matc
 			unmatchedDependents = append(unmatchedDependents, dependent)
 		}
 
+		traceSchoolMatch(inputData, dependent, match, isMatch)
+
 		if isMatch && inputData.devMode {
 			err := writer.Write([]string{
 				fmt.Sprintf("%d", match.ComparableDependent.Dependent.Person.ClaimNumber),
@@ -93,23 +105,65 @@ func PeopleWithChildrenAtNlcSchool(inputData InputData, store PeopleStore) (matc
 				fmt.Sprintf("%f", match.Score),
 			})
 			if err != nil {
-				llog.Println("Error Writing line")
+				inputData.log.Warnf("Couldn't write to the fuzzy matches report: %s", err)
+			} else {
+				inputData.record.addRows(output, 1)
 			}
 
 			if match.ComparableDependent.Dependent.Person.ClaimNumber == inputData.debugClaimNumber {
-				llog.Println("Match with claim num <======")
-				llog.Println(match.ComparableDependent.Dependent.String())
-				llog.Println(match.ComparableDependent.Dependent.Person.String())
+				debugLog := inputData.log.With(claimFields(inputData.debugClaimNumber))
+				debugLog.Infof("Match with claim num <======")
+				debugLog.Infof("%s", match.ComparableDependent.Dependent.String())
+				debugLog.Infof("%s", match.ComparableDependent.Dependent.Person.String())
 			}
 		}
 	}
 
-	llog.Printf("%d dependents in NLC, %d unmatched, out of %d total\n", len(matchedDependents), len(unmatchedDependents), len(allDependents))
-	llog.Printf("%d comparisons\n", numComparisons)
+	err = inputData.cancelled()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inputData.log.Infof("%d dependents in NLC, %d unmatched, out of %d total", len(matchedDependents), len(unmatchedDependents), len(allDependents))
+	inputData.log.Debugf("%d comparisons", numComparisons)
 
 	return matchedDependents, unmatchedDependents, nil
 }
 
+// traceSchoolMatch records the best school roll candidate for the dependent and its scores, and the closest candidates compared
+func traceSchoolMatch(inputData InputData, dependent Dependent, match dependentMatch, isMatch bool) {
+	evidence := Evidence{
+		"school roll rows compared": fmt.Sprintf("%d", match.Comparisons),
+		"match threshold":           scoreEvidence(inputData.schoolMatchThreshold),
+	}
+
+	if match.Row.OriginalRow == nil {
+		evidence["best candidate"] = "none with a similar name and date of birth"
+	} else {
+		evidence["best candidate"] = fmt.Sprintf("SEEMIS %s, %s", match.Row.Seemis, match.Row.OriginalRow.Location())
+		evidence["weighted score"] = scoreEvidence(match.Score)
+		evidence["forename score"] = scoreEvidence(match.ForenameScore)
+		evidence["surname score"] = scoreEvidence(match.SurnameScore)
+		evidence["dob score"] = scoreEvidence(match.DobScore)
+		evidence["address score"] = scoreEvidence(match.AddressScore)
+	}
+
+	for i, candidate := range match.Candidates {
+		evidence[fmt.Sprintf("candidate %d", i+1)] = fmt.Sprintf(
+			"SEEMIS %s, %s, weighted score %s (forename %s, surname %s, dob %s, address %s)",
+			candidate.Row.Seemis, candidate.Row.OriginalRow.Location(), scoreEvidence(candidate.Score),
+			scoreEvidence(candidate.ForenameScore), scoreEvidence(candidate.SurnameScore),
+			scoreEvidence(candidate.DobScore), scoreEvidence(candidate.AddressScore),
+		)
+	}
+
+	if isMatch {
+		inputData.traceDependent(dependent, "matched to the school roll", evidence)
+	} else {
+		inputData.traceDependent(dependent, "not matched to the school roll, reported to education", evidence)
+	}
+}
+
 type dependentMatch struct {
 	ComparableDependent comparableDependent
 	Score               float64
@@ -121,6 +175,38 @@ type dependentMatch struct {
 	StreetScore         float64
 	AddressScore        float64 // highest of postcode or street score
 	DobScore            float64
+	Comparisons         int              // how many school roll rows were compared to find the match
+	Candidates          schoolCandidates // the closest school roll rows compared, for the trace
+}
+
+// schoolMatchCandidates is how many of the closest school roll rows compared to a dependent are traced
+const schoolMatchCandidates = 3
+
+// schoolCandidates are the closest school roll rows compared to a dependent, best first
+type schoolCandidates []dependentMatch
+
+// add keeps the match if it's one of the closest, matches for rows that didn't pass the name and dob prefilter are ignored
+func (c *schoolCandidates) add(match dependentMatch) {
+	if match.Row.OriginalRow == nil {
+		return
+	}
+
+	// A row can be compared more than once, e.g. when it's in the same postcode and has the same surname
+	location := match.Row.OriginalRow.Location()
+	for _, candidate := range *c {
+		if candidate.Row.OriginalRow.Location() == location {
+			return
+		}
+	}
+
+	candidates := append(*c, match)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > schoolMatchCandidates {
+		candidates = candidates[:schoolMatchCandidates]
+	}
+	*c = candidates
 }
 
 // comparablePerson is a Person with cleaned/normalized fields
@@ -161,13 +247,24 @@ func (v schoolRowBySurname) Len() int           { return len(v) }
 func (v schoolRowBySurname) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
 func (v schoolRowBySurname) Less(i, j int) bool { return v[i].Surname < v[j].Surname }
 
-func cacheSchoolRoll(input spreadsheet.ParserInput, store PeopleStore) (allRows []SchoolRollRow, postcodeIndex map[string][]SchoolRollRow, surnameIndex map[string][]SchoolRollRow, err error) {
+func cacheSchoolRoll(inputData InputData, store PeopleStore) (allRows []SchoolRollRow, postcodeIndex map[string][]SchoolRollRow, surnameIndex map[string][]SchoolRollRow, err error) {
 	postcodeIndex = make(map[string][]SchoolRollRow)
 	surnameIndex = make(map[string][]SchoolRollRow)
 	schoolRollRows := []SchoolRollRow{}
-	err = spreadsheet.EachRow(input, func(r spreadsheet.Row) {
+
+	// The first issue to stop the run, which stops the remaining rows being read
+	var valueErr error
+
+	err = spreadsheet.EachRow(inputData.schoolRoll, func(r spreadsheet.Row) {
+		if valueErr != nil || isBlankSchoolRollRow(r) {
+			return
+		}
+
 		row, err := NewSchoolRollRow(r)
 		if err != nil {
+			// The pupil can't be matched without a dob, so is left out rather than silently dropped
+			dobStr := spreadsheet.ColByName(r, "Date of Birth")
+			valueErr = inputData.claimIssue(r, IssueInvalidDob, 0, ErrInvalidValue{location: r.Location(), name: "dob", column: "Date of Birth", value: dobStr})
 			return
 		}
 
@@ -188,48 +285,78 @@ func cacheSchoolRoll(input spreadsheet.ParserInput, store PeopleStore) (allRows
 
 		surnameIndex[row.Surname] = append(surnameIndex[row.Surname], row)
 	})
+	if err == nil {
+		err = valueErr
+	}
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	llog.Printf("Generated postcode index with %d items\n", len(postcodeIndex))
-	llog.Printf("Generated surname index with %d items\n", len(surnameIndex))
-	llog.Printf("Loaded %d items into memory\n", len(schoolRollRows))
+	inputData.log.Debugf("Generated postcode index with %d items", len(postcodeIndex))
+	inputData.log.Debugf("Generated surname index with %d items", len(surnameIndex))
+	inputData.log.Debugf("Loaded %d items into memory", len(schoolRollRows))
 	sort.Sort(schoolRowBySurname(schoolRollRows))
 
 	return schoolRollRows, postcodeIndex, surnameIndex, err
 }
 
-func checkSchoolRoll(wg *sync.WaitGroup, matchesChan chan dependentMatch, d comparableDependent, rowsToSearch [][]SchoolRollRow) {
+func checkSchoolRoll(inputData InputData, wg *sync.WaitGroup, matchesChan chan dependentMatch, d comparableDependent, rowsToSearch [][]SchoolRollRow) {
 	defer wg.Done()
 
 	bestMatch := dependentMatch{
 		ComparableDependent: d,
 	}
+	candidates := schoolCandidates{}
+	comparisons := 0
 	for _, rows := range rowsToSearch {
-		matched, match := isInSchoolRollRows(d, rows)
+		matched, match, compared := isInSchoolRollRows(inputData, d, rows, &candidates)
+		comparisons += compared
+
+		// The matches of a cancelled run aren't used
+		if inputData.cancelled() != nil {
+			return
+		}
 
 		if match.Score > bestMatch.Score {
 			bestMatch = match
 		}
 
 		if matched {
+			match.Comparisons = comparisons
+			match.Candidates = candidates
 			matchesChan <- match
 			return
 		}
 	}
 
+	bestMatch.Comparisons = comparisons
+	bestMatch.Candidates = candidates
 	matchesChan <- bestMatch
 }
 
-func isInSchoolRollRows(d comparableDependent, rows []SchoolRollRow) (bool, dependentMatch) {
-	for _, row := range rows {
-		matched, match := row.isFuzzyMatch(d.ComparablePerson, d)
+// isInSchoolRollRows returns the first of the rows matching the dependent, or the closest if none match,
+// and how many rows were compared. The closest rows compared are added to candidates.
+func isInSchoolRollRows(inputData InputData, d comparableDependent, rows []SchoolRollRow, candidates *schoolCandidates) (bool, dependentMatch, int) {
+	closest := dependentMatch{}
+	done := inputData.done()
+	for i, row := range rows {
+		select {
+		case <-done:
+			return false, closest, i
+		default:
+		}
+
+		matched, match := row.isFuzzyMatch(inputData, d.ComparablePerson, d)
+		candidates.add(match)
 		if matched {
-			return true, match
+			return true, match, i + 1
+		}
+
+		if match.Score > closest.Score {
+			closest = match
 		}
 	}
 
-	return false, dependentMatch{}
+	return false, closest, len(rows)
 }
 
 // SchoolRollRow represents the columns we care about from the school roll
@@ -255,10 +382,19 @@ func cleanedColByName(r spreadsheet.Row, colName string) string {
 	return CleanString(rowValue)
 }
 
+// isBlankSchoolRollRow returns true for a row without a pupil, e.g. a blank row at the end of the sheet
+func isBlankSchoolRollRow(r spreadsheet.Row) bool {
+	for _, column := range []string{"Forename", "Surname", "Date of Birth"} {
+		if strings.TrimSpace(spreadsheet.ColByName(r, column)) != "" {
+			return false
+		}
+	}
+	return true
+}
+
 // NewSchoolRollRow creates a SchoolRollRow struct from a row in the school roll spreadsheet
 func NewSchoolRollRow(r spreadsheet.Row) (SchoolRollRow, error) {
-	dobStr := spreadsheet.ColByName(r, "Date of Birth")
-	dob, err := time.Parse("2-Jan-06", dobStr)
+	dob, err := spreadsheet.DateColByName(r, "Date of Birth", "2-Jan-06", "2-Jan-2006", "2006-01-02")
 	if err != nil {
 		return SchoolRollRow{}, err
 	}
@@ -279,13 +415,12 @@ func NewSchoolRollRow(r spreadsheet.Row) (SchoolRollRow, error) {
 
 // isFuzzyMatch determins if the dependent/person pair are a match for
 // a school roll row
-func (r SchoolRollRow) isFuzzyMatch(person comparablePerson, d comparableDependent) (bool, dependentMatch) {
-	numComparisons++
+func (r SchoolRollRow) isFuzzyMatch(inputData InputData, person comparablePerson, d comparableDependent) (bool, dependentMatch) {
 	forenameScore := CompareStrings(d.Forename, r.Forename)
 	surnameScore := CompareStrings(d.Surname, r.Surname)
 
 	combinedNameScore := (forenameScore + surnameScore) / 2
-	if combinedNameScore < 0.7 {
+	if combinedNameScore < inputData.namePrefilterThreshold {
 		return false, dependentMatch{}
 	}
 
@@ -303,7 +438,7 @@ func (r SchoolRollRow) isFuzzyMatch(person comparablePerson, d comparableDepende
 	addressScore := math.Max(postcodeScore, streetScore)
 
 	aggregateScore := calculateWeightedScore(forenameScore, surnameScore, dobScore, addressScore)
-	match := aggregateScore >= definiteMatchThreshold
+	match := aggregateScore >= inputData.schoolMatchThreshold
 
 	if match {
 		d.Dependent.Seemis = r.Seemis
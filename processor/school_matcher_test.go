@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/addjam/fsm-processor/spreadsheet"
+)
+
+// schoolRollTestRow is a school roll row at a line of the file
+type schoolRollTestRow int
+
+func (r schoolRollTestRow) Headers() []string { return nil }
+func (r schoolRollTestRow) Col(int) string    { return "" }
+func (r schoolRollTestRow) Location() spreadsheet.Location {
+	return spreadsheet.Location{Path: "school roll.csv", Row: int(r)}
+}
+
+func TestCompareStrings(t *testing.T) {
+	t.Run("identical names = 1", func(t *testing.T) {
+		nameA := "chris"
+		nameB := "chris"
+
+		score := CompareStrings(nameA, nameB)
+
+		if score < 1 {
+			t.Fatalf("Expected score 1 but got %f", score)
+		}
+	})
+
+	t.Run("similar names have a high score", func(t *testing.T) {
+		nameA := "chris"
+		nameB := "chriss"
+
+		score := CompareStrings(nameA, nameB)
+
+		if score < 0.9 {
+			t.Fatalf("Expected score > 0.9 but got %f", score)
+		}
+	})
+
+	t.Run("different names have a low score", func(t *testing.T) {
+		nameA := "chris"
+		nameB := "bob"
+
+		score := CompareStrings(nameA, nameB)
+
+		if score > 0.1 {
+			t.Fatalf("Expected score < 0.1 but got %f", score)
+		}
+	})
+}
+
+func TestIsInSchoolRollRows(t *testing.T) {
+	t.Run("Stops comparing once the run is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		inputData := InputData{}.withContext(ctx)
+		rows := []SchoolRollRow{{Forename: "chris"}, {Forename: "chris"}}
+
+		matched, _, compared := isInSchoolRollRows(inputData, comparableDependent{Forename: "chris"}, rows, &schoolCandidates{})
+
+		if matched || compared != 0 {
+			t.Errorf("Expected no rows to be compared but got %d", compared)
+		}
+	})
+}
+
+func TestSchoolCandidates(t *testing.T) {
+	candidate := func(line int, score float64) dependentMatch {
+		return dependentMatch{Score: score, Row: SchoolRollRow{Seemis: "1", OriginalRow: schoolRollTestRow(line)}}
+	}
+
+	t.Run("Keeps the closest rows, best first", func(t *testing.T) {
+		candidates := schoolCandidates{}
+		for i, score := range []float64{0.5, 0.9, 0.7, 0.8} {
+			candidates.add(candidate(i+2, score))
+		}
+
+		if len(candidates) != schoolMatchCandidates {
+			t.Fatalf("Expected %d candidates but got %#v", schoolMatchCandidates, candidates)
+		}
+		for i, score := range []float64{0.9, 0.8, 0.7} {
+			if candidates[i].Score != score {
+				t.Errorf("Expected candidate %d to score %f but got %#v", i+1, score, candidates[i])
+			}
+		}
+	})
+
+	t.Run("Ignores rows already compared and rows not passing the prefilter", func(t *testing.T) {
+		candidates := schoolCandidates{}
+		candidates.add(candidate(2, 0.9))
+		candidates.add(candidate(2, 0.9))
+		candidates.add(dependentMatch{})
+
+		if len(candidates) != 1 {
+			t.Errorf("Expected 1 candidate but got %#v", candidates)
+		}
+	})
+
+	t.Run("Traces each candidate with its scores", func(t *testing.T) {
+		inputData := InputData{trace: &Trace{}}
+		match := candidate(3, 0.9)
+		match.Candidates = schoolCandidates{match, candidate(2, 0.8)}
+
+		traceSchoolMatch(inputData, Dependent{}, match, false)
+
+		decisions := inputData.trace.Decisions()
+		if len(decisions) != 1 {
+			t.Fatalf("Expected 1 decision but got %#v", decisions)
+		}
+		expected := "SEEMIS 1, \"school roll.csv\" line 2, weighted score 0.800000 (forename 0.000000, surname 0.000000, dob 0.000000, address 0.000000)"
+		if decisions[0].Evidence["candidate 2"] != expected {
+			t.Errorf("Expected %s but got %#v", expected, decisions[0].Evidence)
+		}
+	})
+}
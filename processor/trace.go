@@ -0,0 +1,280 @@
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// traceFile is the file name the trace of a run is written to in the output folder
+const traceFile = "trace.ndjson"
+
+// StageLetter is the name given to choosing the letter of a dependent in the award list
+const StageLetter = "letter"
+
+// Evidence is the data a decision was based on, by name
+type Evidence map[string]string
+
+// Decision is what a stage decided about a claim, or one of its dependents, and the evidence it used
+type Decision struct {
+	Algorithm   string   `json:"algorithm"` // FSM or CTR
+	Stage       string   `json:"stage"`
+	ClaimNumber int      `json:"claim_number"`
+	Nino        string   `json:"nino,omitempty"`
+	Dependent   string   `json:"dependent,omitempty"` // forename and surname, empty for decisions about the claim
+	Seemis      string   `json:"seemis,omitempty"`
+	Outcome     string   `json:"outcome"`
+	Evidence    Evidence `json:"evidence,omitempty"`
+}
+
+// Trace records the decisions of a run for every claim and dependent, so the outcome for anyone can be
+// explained. It's safe for concurrent use, and a nil Trace records nothing.
+type Trace struct {
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// Add records a decision
+func (t *Trace) Add(decision Decision) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.decisions = append(t.decisions, decision)
+}
+
+// Decisions returns every decision recorded so far
+func (t *Trace) Decisions() []Decision {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	decisions := make([]Decision, len(t.decisions))
+	copy(decisions, t.decisions)
+	return decisions
+}
+
+// Explain returns the decisions about the claim with the claim number or NINO, or the dependent
+// with the SEEMIS reference. For a dependent, the decisions about its claim are included too.
+func (t *Trace) Explain(query string) []Decision {
+	query = strings.TrimSpace(query)
+	claimNumber, claimErr := strconv.Atoi(query)
+	nino := CleanString(query)
+
+	claims := map[int]bool{}          // claims matching the query
+	dependentClaims := map[int]bool{} // claims of the dependents matching the query
+	dependents := map[dependentKey]bool{}
+
+	decisions := t.Decisions()
+	for _, d := range decisions {
+		if (claimErr == nil && d.ClaimNumber == claimNumber) || (nino != "" && CleanString(d.Nino) == nino) {
+			claims[d.ClaimNumber] = true
+		}
+		if d.Seemis != "" && d.Seemis == query {
+			dependents[dependentKey{d.ClaimNumber, d.Dependent}] = true
+			dependentClaims[d.ClaimNumber] = true
+		}
+	}
+
+	explained := []Decision{}
+	for _, d := range decisions {
+		isDependent := dependents[dependentKey{d.ClaimNumber, d.Dependent}]
+		isClaimOfDependent := d.Dependent == "" && dependentClaims[d.ClaimNumber]
+
+		if claims[d.ClaimNumber] || isDependent || isClaimOfDependent {
+			explained = append(explained, d)
+		}
+	}
+
+	return explained
+}
+
+// dependentKey identifies a dependent in a trace, as decisions made before school roll matching have no SEEMIS reference
+type dependentKey struct {
+	claimNumber int
+	name        string
+}
+
+// Write writes the decisions as newline delimited json, one decision per line
+func (t *Trace) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, decision := range t.Decisions() {
+		err := encoder.Encode(decision)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadTrace reads a trace written by a run, e.g. the trace.ndjson file in its output folder
+func ReadTrace(path string) (*Trace, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	trace := &Trace{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		decision := Decision{}
+		err = json.Unmarshal(scanner.Bytes(), &decision)
+		if err != nil {
+			return nil, ErrInvalidTrace{filePath: path, line: line, reason: err.Error()}
+		}
+
+		trace.Add(decision)
+	}
+
+	return trace, scanner.Err()
+}
+
+// RenderDecisions formats the decisions as text, a line per decision followed by its evidence
+func RenderDecisions(decisions []Decision) string {
+	var b strings.Builder
+
+	for _, d := range decisions {
+		fmt.Fprintf(&b, "%s %s: claim %d", d.Algorithm, d.Stage, d.ClaimNumber)
+		if d.Dependent != "" {
+			fmt.Fprintf(&b, ", %s", d.Dependent)
+		}
+		if d.Seemis != "" {
+			fmt.Fprintf(&b, " (SEEMIS %s)", d.Seemis)
+		}
+		fmt.Fprintf(&b, " - %s\n", d.Outcome)
+
+		names := []string{}
+		for name := range d.Evidence {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&b, "    %s: %s\n", name, d.Evidence[name])
+		}
+	}
+
+	return b.String()
+}
+
+// tracePerson records the decision the current stage made about a claim
+func (i InputData) tracePerson(p Person, outcome string, evidence Evidence) {
+	i.trace.Add(Decision{
+		Algorithm:   i.algorithm,
+		Stage:       i.stage,
+		ClaimNumber: p.ClaimNumber,
+		Nino:        p.Nino,
+		Outcome:     outcome,
+		Evidence:    evidence,
+	})
+}
+
+// traceDependent records the decision the current stage made about a dependent
+func (i InputData) traceDependent(d Dependent, outcome string, evidence Evidence) {
+	i.trace.Add(Decision{
+		Algorithm:   i.algorithm,
+		Stage:       i.stage,
+		ClaimNumber: d.Person.ClaimNumber,
+		Nino:        d.Person.Nino,
+		Dependent:   strings.TrimSpace(d.Forename + " " + d.Surname),
+		Seemis:      d.Seemis,
+		Outcome:     outcome,
+		Evidence:    evidence,
+	})
+}
+
+// traceFilter records which of the dependents were kept by a stage filtering them, evidence is optional
+func (i InputData) traceFilter(before []Dependent, after []Dependent, kept string, removed string, evidence func(d Dependent) Evidence) {
+	keptSeemis := map[string]bool{}
+	for _, d := range after {
+		keptSeemis[d.Seemis] = true
+	}
+
+	for _, d := range before {
+		outcome := removed
+		if keptSeemis[d.Seemis] {
+			outcome = kept
+		}
+
+		var e Evidence
+		if evidence != nil {
+			e = evidence(d)
+		}
+		i.traceDependent(d, outcome, e)
+	}
+}
+
+// traceLetters records the letter chosen for each dependent in the award list
+func (i InputData) traceLetters(dependents []Dependent) {
+	i.stage = StageLetter
+
+	for _, d := range dependents {
+		letter := LetterForDependent(d, i.rolloverMode)
+		evidence := entitlementEvidence(d)
+		evidence["consent"] = d.Person.ConsentStr()
+		evidence["rollover"] = yesNoEvidence(i.rolloverMode)
+
+		if letter == NoLetter {
+			i.traceDependent(d, "no letter", evidence)
+		} else {
+			i.traceDependent(d, "letter "+letter.String(), evidence)
+		}
+	}
+}
+
+// writeTrace writes the trace of the run to the output folder
+func writeTrace(inputData InputData, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	output := inputData.record.addOutput(filePath, "")
+	buffered := bufio.NewWriter(file)
+	err = inputData.trace.Write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		rows := len(inputData.trace.Decisions())
+		inputData.record.addRows(output, rows)
+		inputData.progress.emit(Event{Event: EventFileWritten, Path: filePath, Rows: rows})
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Helpers formatting evidence
+
+func moneyEvidence(value float32) string {
+	return fmt.Sprintf("%.2f", value)
+}
+
+func scoreEvidence(score float64) string {
+	return fmt.Sprintf("%f", score)
+}
+
+func yesNoEvidence(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testTrace() *Trace {
+	trace := &Trace{}
+	trace.Add(Decision{Algorithm: PhaseFsm, Stage: StageConsent, ClaimNumber: 1, Nino: "AB123456C", Outcome: "consent given"})
+	trace.Add(Decision{Algorithm: PhaseFsm, Stage: StageHousehold, ClaimNumber: 1, Nino: "AB123456C", Dependent: "Ann Smith", Outcome: "child in household"})
+	trace.Add(Decision{Algorithm: PhaseFsm, Stage: StageHousehold, ClaimNumber: 1, Nino: "AB123456C", Dependent: "Bob Smith", Outcome: "child in household"})
+	trace.Add(Decision{
+		Algorithm:   PhaseFsm,
+		Stage:       StageSchoolMatch,
+		ClaimNumber: 1,
+		Nino:        "AB123456C",
+		Dependent:   "Ann Smith",
+		Seemis:      "123",
+		Outcome:     "matched to the school roll",
+		Evidence:    Evidence{"weighted score": "0.970000"},
+	})
+	trace.Add(Decision{Algorithm: PhaseFsm, Stage: StageConsent, ClaimNumber: 2, Nino: "CD654321E", Outcome: "no consent"})
+	return trace
+}
+
+func TestTraceExplain(t *testing.T) {
+	trace := testTrace()
+
+	t.Run("Explains a claim by claim number", func(t *testing.T) {
+		decisions := trace.Explain("1")
+
+		if len(decisions) != 4 {
+			t.Errorf("Expected the 4 decisions about claim 1 but got %#v", decisions)
+		}
+	})
+
+	t.Run("Explains a claim by NINO", func(t *testing.T) {
+		decisions := trace.Explain("cd 65 43 21 e")
+
+		if len(decisions) != 1 || decisions[0].ClaimNumber != 2 {
+			t.Errorf("Expected the decision about claim 2 but got %#v", decisions)
+		}
+	})
+
+	t.Run("Explains a dependent by SEEMIS reference with its claim", func(t *testing.T) {
+		decisions := trace.Explain("123")
+
+		if len(decisions) != 3 {
+			t.Fatalf("Expected 3 decisions but got %#v", decisions)
+		}
+		for _, d := range decisions {
+			if d.Dependent == "Bob Smith" {
+				t.Errorf("Expected only decisions about Ann Smith and her claim but got %#v", d)
+			}
+		}
+	})
+
+	t.Run("Explains nothing for an unknown query", func(t *testing.T) {
+		if decisions := trace.Explain("999"); len(decisions) != 0 {
+			t.Errorf("Expected no decisions but got %#v", decisions)
+		}
+	})
+}
+
+func TestTraceFile(t *testing.T) {
+	t.Run("Reads a written trace", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), traceFile)
+		inputData := InputData{trace: testTrace(), record: &runRecord{}}
+
+		err := writeTrace(inputData, path)
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		trace, err := ReadTrace(path)
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		decisions := trace.Decisions()
+		if len(decisions) != 5 || decisions[3].Evidence["weighted score"] != "0.970000" {
+			t.Errorf("Expected the decisions written but got %#v", decisions)
+		}
+		if outputs := inputData.record.Outputs(); len(outputs) != 1 || outputs[0].Rows != 5 {
+			t.Errorf("Expected the trace to be recorded as an output but got %#v", outputs)
+		}
+	})
+
+	t.Run("Returns an error for an invalid line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), traceFile)
+		var b bytes.Buffer
+		testTrace().Write(&b)
+		b.WriteString("not json\n")
+		ioutil.WriteFile(path, b.Bytes(), 0644)
+
+		_, err := ReadTrace(path)
+
+		if _, ok := err.(ErrInvalidTrace); !ok {
+			t.Errorf("Expected ErrInvalidTrace but got %#v", err)
+		}
+	})
+}
+
+func TestRenderDecisions(t *testing.T) {
+	text := RenderDecisions(testTrace().Explain("123"))
+
+	expected := "FSM school match: claim 1, Ann Smith (SEEMIS 123) - matched to the school roll\n    weighted score: 0.970000\n"
+	if !strings.Contains(text, expected) {
+		t.Errorf("Expected the school match decision with its evidence but got %s", text)
+	}
+}
+
+func TestTraceFilter(t *testing.T) {
+	inputData := InputData{trace: &Trace{}, algorithm: PhaseCtr, stage: StageMinimumP1}
+	before := []Dependent{{Seemis: "1", YearGroup: "P1"}, {Seemis: "2", YearGroup: "N1"}}
+
+	inputData.traceFilter(before, before[:1], "kept", "removed", func(d Dependent) Evidence {
+		return Evidence{"year group": d.YearGroup}
+	})
+
+	decisions := inputData.trace.Decisions()
+	if len(decisions) != 2 || decisions[0].Outcome != "kept" || decisions[1].Outcome != "removed" {
+		t.Fatalf("Expected the first dependent kept and second removed but got %#v", decisions)
+	}
+	if decisions[1].Algorithm != PhaseCtr || decisions[1].Stage != StageMinimumP1 || decisions[1].Evidence["year group"] != "N1" {
+		t.Errorf("Expected the stage and evidence of the decision but got %#v", decisions[1])
+	}
+}
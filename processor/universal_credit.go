@@ -0,0 +1,46 @@
+package processor
+
+import "github.com/addjam/fsm-processor/spreadsheet"
+
+// universalCreditLayout is the record layout of the DWP hb-uc.d file. It's space separated with
+// one record per claim update, followed by trailing summary data. Only the claim number, sequence
+// number and benefit amount are read or traced. The other fields aren't used so they're named by
+// their position in the record, e.g. "Field 3", rather than given a meaning they haven't been checked
+// against, but are still listed so a record with more or fewer fields is rejected by the layout.
+var universalCreditLayout = &spreadsheet.RecordLayout{
+	Name:    "hb-uc.d",
+	Trailer: true,
+	Fields: []spreadsheet.Field{
+		{Name: "Field 1"},
+		{Name: "Claim Number", Type: spreadsheet.FieldInt},
+		{Name: "Field 3"},
+		{Name: "Field 4"},
+		{Name: "Field 5"},
+		{Name: "Field 6"},
+		{Name: "Field 7"},
+		{Name: "Field 8"},
+		{Name: "Field 9"},
+		{Name: "Field 10"},
+		{Name: "Field 11"},
+		{Name: "Field 12"},
+		{Name: "Field 13"},
+		{Name: "Field 14"},
+		{Name: "Field 15"},
+		{Name: "Sequence Number", Type: spreadsheet.FieldInt},
+		{Name: "Field 17"},
+		{Name: "Field 18"},
+		{Name: "Field 19"},
+		{Name: "Field 20"},
+		{Name: "Field 21"},
+		{Name: "Field 22"},
+		{Name: "Field 23"},
+		{Name: "Field 24"},
+		{Name: "Field 25"},
+		{Name: "Field 26"},
+		{Name: "Benefit Amount", Type: spreadsheet.FieldMoney},
+		{Name: "Field 28"},
+		{Name: "Field 29"},
+		{Name: "Field 30"},
+		{Name: "Field 31"},
+	},
+}
@@ -0,0 +1,30 @@
+package processor
+
+import "github.com/addjam/fsm-processor/spreadsheet"
+
+// InputValidation is the result of checking an input against its schema
+type InputValidation struct {
+	Input  string              `json:"input"`
+	Path   string              `json:"path"`
+	Valid  bool                `json:"valid"`
+	Issues []spreadsheet.Issue `json:"issues"`
+}
+
+// ValidateInputs checks every input against its schema without generating any awards
+func ValidateInputs(inputData InputData) []InputValidation {
+	results := []InputValidation{}
+
+	schemas := inputData.schemas()
+	for i, input := range inputData.inputs() {
+		issues := spreadsheet.Validate(*input, schemas[i])
+
+		results = append(results, InputValidation{
+			Input:  schemas[i].Name,
+			Path:   input.Path,
+			Valid:  len(issues) == 0,
+			Issues: issues,
+		})
+	}
+
+	return results
+}
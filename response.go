@@ -7,27 +7,50 @@ import (
 	"os"
 
 	"github.com/addjam/fsm-processor/llog"
+	"github.com/addjam/fsm-processor/processor"
 )
 
-// RespondWith stops execution and outputs response data as json
+// OutputVersion is the version of the json output schema, incremented when fields are changed or removed
+const OutputVersion = 3
+
+// RespondWith stops execution and outputs the result of a run as json
 //
-// fsmStore - PeopleStore representing the final state of the FSM algorithm data
-// ctrStore - PeopleStore representing the final state of the FSM algorithm data
+// result - the data of the run, including the final state of the FSM and CTR algorithm data
 // err - optional error that halted execution
-func RespondWith(fsmStore *PeopleStore, ctrStore *PeopleStore, err error) {
+func RespondWith(result processor.Result, err error) {
 	output := Output{
-		Success: err == nil,
+		Version:     OutputVersion,
+		Success:     err == nil,
+		Cancelled:   result.Cancelled,
+		Log:         &result.Log,
+		Inputs:      result.Inputs,
+		Outputs:     result.Outputs,
+		FsmFunnel:   result.FsmFunnel,
+		CtrFunnel:   result.CtrFunnel,
+		DataQuality: result.DataQuality,
+		Validation:  result.Validation,
+		Timings:     result.Timings,
+		Config:      &result.Config,
 	}
 
 	if err != nil {
 		output.Error = err.Error()
 	}
 
-	output.FsmDebugData = generateDebugData(fsmStore)
-	output.CtrDebugData = generateDebugData(ctrStore)
-	output.Log = llog.Data()
+	if result.Fsm != nil {
+		summary := result.Fsm.Summary()
+		output.Fsm = &summary
+	}
+	if result.Ctr != nil {
+		summary := result.Ctr.Summary()
+		output.Ctr = &summary
+	}
+
+	respond(output)
+}
 
-	// Output as json
+// respond outputs as json and exits
+func respond(output Output) {
 	json, err := json.Marshal(output)
 	if err != nil {
 		log.Fatal(`{ "success": false, "error": "Error marshalling json from store" }`)
@@ -35,29 +58,95 @@ func RespondWith(fsmStore *PeopleStore, ctrStore *PeopleStore, err error) {
 
 	fmt.Print(string(json))
 
-	if !output.Success {
+	if output.Cancelled {
+		os.Exit(2)
+	} else if !output.Success {
 		os.Exit(1)
 	} else {
 		os.Exit(0)
 	}
 }
 
-// Output represents the result data
-type Output struct {
-	Success      bool   `json:"success"`
-	FsmDebugData string `json:"fsm_debug,omitempty"`
-	CtrDebugData string `json:"ctr_debug,omitempty"`
-	Error        string `json:"error,omitempty"`
-	Log          string `json:"log"`
+// RespondWithSheets stops execution and outputs the sheet names of a workbook as json
+func RespondWithSheets(sheets []string, err error) {
+	output := Output{
+		Version: OutputVersion,
+		Success: err == nil,
+		Sheets:  sheets,
+	}
+
+	if err != nil {
+		output.Error = err.Error()
+	}
+
+	respond(output)
 }
 
-func generateDebugData(store *PeopleStore) string {
-	if store == nil {
-		return "No data"
+// RespondWithExplanation stops execution and outputs the decisions about a claim or dependent,
+// as json or just the rendered text in text mode
+func RespondWithExplanation(decisions []processor.Decision, err error, textMode bool) {
+	if textMode {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Print(processor.RenderDecisions(decisions))
+		os.Exit(0)
 	}
 
-	return fmt.Sprintf(`
-		%d people in store,
-	`,
-		len(store.People))
+	output := Output{
+		Version:     OutputVersion,
+		Success:     err == nil,
+		Trace:       decisions,
+		Explanation: processor.RenderDecisions(decisions),
+	}
+
+	if err != nil {
+		output.Error = err.Error()
+	}
+
+	respond(output)
+}
+
+// Output represents the result data
+type Output struct {
+	Version int      `json:"version"`
+	Success bool     `json:"success"`
+	Error   string   `json:"error,omitempty"`
+	Sheets  []string `json:"sheets,omitempty"`
+
+	// Cancelled is true if the run was interrupted or timed out, the files it had written are deleted
+	Cancelled bool `json:"cancelled,omitempty"`
+
+	// Log summarises the warnings and errors logged, the full log is written with -log or -logfile
+	Log *llog.Summary `json:"log,omitempty"`
+
+	// Inputs identifies the version of each input read, Outputs lists the files written and their rows
+	Inputs  []processor.InputFile  `json:"inputs,omitempty"`
+	Outputs []processor.OutputFile `json:"outputs,omitempty"`
+
+	// The counts of the final FSM and CTR data, omitted if the run stopped before reaching them
+	Fsm *processor.StoreSummary `json:"fsm,omitempty"`
+	Ctr *processor.StoreSummary `json:"ctr,omitempty"`
+
+	// The number of people and dependents before and after each stage, for drawing a funnel
+	FsmFunnel []processor.StageStats `json:"fsm_funnel,omitempty"`
+	CtrFunnel []processor.StageStats `json:"ctr_funnel,omitempty"`
+
+	// DataQuality lists the problems found with the data in the inputs, and what was done about each
+	DataQuality []processor.DataIssue `json:"data_quality"`
+
+	// Validation has the results of checking each input against its schema in validate mode
+	Validation []processor.InputValidation `json:"validation,omitempty"`
+
+	// Timings has how long each phase of the run took
+	Timings []processor.Timing `json:"timings,omitempty"`
+
+	// Config is the effective config of the run, so it can be reproduced
+	Config *processor.Config `json:"config,omitempty"`
+
+	// The decisions about a claim or dependent, and them rendered as text, for the explain command
+	Trace       []processor.Decision `json:"trace,omitempty"`
+	Explanation string               `json:"explanation,omitempty"`
 }
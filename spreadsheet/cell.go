@@ -0,0 +1,135 @@
+package spreadsheet
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+// CellType is the type of value stored in a spreadsheet cell
+type CellType int
+
+const (
+	// CellEmpty is a cell with no value
+	CellEmpty CellType = iota
+
+	// CellString is a text cell
+	CellString
+
+	// CellNumber is a numeric cell that isn't formatted as a date
+	CellNumber
+
+	// CellDate is a numeric cell formatted as a date and/or time
+	CellDate
+
+	// CellBool is a TRUE/FALSE cell
+	CellBool
+
+	// CellError is a cell showing an error such as #DIV/0!
+	CellError
+)
+
+// Cell is the typed value of a spreadsheet cell. For formulas this is the
+// result cached when the file was last saved.
+type Cell struct {
+	Type    CellType
+	Text    string    // Formatted text, the same as returned by Row.Col
+	Number  float64   // Set for CellNumber, CellDate (as an excel serial date) and CellBool (1 or 0)
+	Date    time.Time // Set for CellDate
+	Formula bool      // If the value is the cached result of a formula
+}
+
+// TypedRow is a Row from a format that stores typed cell values, i.e. xls and xlsx.
+// CSV rows only contain text so don't implement it.
+type TypedRow interface {
+	Row
+	Cell(int) Cell
+}
+
+// TextCell creates a text cell. Used for identifiers such as references with leading
+// zeros, which shouldn't be converted to numbers.
+func TextCell(text string) Cell {
+	if text == "" {
+		return Cell{Type: CellEmpty}
+	}
+
+	return Cell{Type: CellString, Text: text}
+}
+
+// NumberCell creates a numeric cell
+func NumberCell(number float64) Cell {
+	return Cell{Type: CellNumber, Text: strconv.FormatFloat(number, 'f', -1, 64), Number: number}
+}
+
+// DateCell creates a date cell, its text is the date formatted with the layout
+func DateCell(date time.Time, layout string) Cell {
+	return Cell{Type: CellDate, Text: date.Format(layout), Date: date}
+}
+
+// xlsxCell converts a cell loaded by the xlsx library
+func xlsxCell(c *xlsx.Cell, date1904 bool) Cell {
+	cell := Cell{
+		Type:    CellString,
+		Text:    c.String(),
+		Formula: c.Formula() != "",
+	}
+
+	if c.Value == "" {
+		cell.Type = CellEmpty
+		return cell
+	}
+
+	switch c.Type() {
+	case xlsx.CellTypeNumeric:
+		number, err := c.Float()
+		if err != nil {
+			return cell
+		}
+
+		cell.Type = CellNumber
+		cell.Number = number
+		if c.IsTime() {
+			cell.Type = CellDate
+			cell.Date = xlsx.TimeFromExcelTime(number, date1904)
+		}
+	case xlsx.CellTypeBool:
+		cell.Type = CellBool
+		if c.Bool() {
+			cell.Number = 1
+		}
+	case xlsx.CellTypeError:
+		cell.Type = CellError
+	}
+
+	return cell
+}
+
+// inferredCell creates a Cell from formatted text by inferring the type
+// of value. Used where the underlying library doesn't expose cell types.
+// Only text in the form the library writes numbers in is a CellNumber, so
+// text such as the reference "000017" stays a CellString with its zeros.
+func inferredCell(text string) Cell {
+	if text == "" {
+		return Cell{Type: CellEmpty}
+	}
+
+	if date, err := time.Parse(time.RFC3339, text); err == nil {
+		return Cell{Type: CellDate, Text: text, Date: date}
+	}
+
+	if number, err := strconv.ParseFloat(text, 64); err == nil && isFinite(number) && strconv.FormatFloat(number, 'f', -1, 64) == text {
+		return Cell{Type: CellNumber, Text: text, Number: number}
+	}
+
+	return Cell{Type: CellString, Text: text}
+}
+
+// cellTexts returns the formatted text of each cell
+func cellTexts(cells []Cell) []string {
+	texts := make([]string, len(cells))
+	for i, cell := range cells {
+		texts[i] = cell.Text
+	}
+	return texts
+}
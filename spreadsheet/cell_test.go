@@ -0,0 +1,107 @@
+package spreadsheet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestXlsxCells(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		parser, err := NewXlsxParser(ParserInput{Path: "./testdata/Consent Report W360.xlsx", HasHeaders: true, Stream: stream})
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+
+		parser.Next()
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		typed, ok := row.(TypedRow)
+		if !ok {
+			t.Fatalf("Expected xlsx rows to be typed")
+		}
+
+		if cell := typed.Cell(0); cell.Type != CellString || cell.Text != "FSM&CG Consent Removed" {
+			t.Fatalf("Expected a string cell but got %#v", cell)
+		}
+
+		if cell := typed.Cell(1); cell.Type != CellNumber || cell.Number != 43446.058159722219 {
+			t.Fatalf("Expected a number cell but got %#v", cell)
+		}
+
+		if cell := typed.Cell(3); cell.Type != CellEmpty {
+			t.Fatalf("Expected an empty cell but got %#v", cell)
+		}
+
+		date, err := DateColByName(row, "DocDate", "02/01/06 15:04:05")
+		want := time.Date(2018, 12, 12, 1, 23, 45, 0, time.UTC)
+		if err != nil || !date.Equal(want) {
+			t.Fatalf("Expected %v but got %v (%v)", want, date, err)
+		}
+
+		parser.Close()
+	}
+}
+
+func TestXlsCells(t *testing.T) {
+	parser, err := NewXlsParser(ParserInput{Path: "./testdata/Consent Report W360.xls", HasHeaders: true})
+	if err != nil {
+		t.Fatalf("Error creating parser")
+	}
+	defer parser.Close()
+
+	parser.Next()
+	row, err := parser.Next()
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+
+	typed, ok := row.(TypedRow)
+	if !ok {
+		t.Fatalf("Expected xls rows to be typed")
+	}
+
+	if cell := typed.Cell(0); cell.Type != CellString || cell.Text != "FSM&CG Consent Removed" {
+		t.Fatalf("Expected a string cell but got %#v", cell)
+	}
+
+	// The xlsx parser returns this date as a CellNumber, the xls library only gives its formatted text
+	if cell := typed.Cell(1); cell.Type != CellString || cell.Text != "12/12/18 12:12:12" {
+		t.Fatalf("Expected the date in a custom format to be a string cell but got %#v", cell)
+	}
+
+	if cell := typed.Cell(3); cell.Type != CellEmpty {
+		t.Fatalf("Expected an empty cell but got %#v", cell)
+	}
+
+	// The same dates are read from both formats with the layout of the text
+	date, err := DateColByName(row, "DocDate", "02/01/06 15:04:05")
+	want := time.Date(2018, 12, 12, 12, 12, 12, 0, time.UTC)
+	if err != nil || !date.Equal(want) {
+		t.Fatalf("Expected %v but got %v (%v)", want, date, err)
+	}
+}
+
+func TestInferredCell(t *testing.T) {
+	if cell := inferredCell("2018-12-12T01:23:45Z"); cell.Type != CellDate || cell.Date.Day() != 12 {
+		t.Fatalf("Expected a date cell but got %#v", cell)
+	}
+
+	if cell := inferredCell("12.5"); cell.Type != CellNumber || cell.Number != 12.5 {
+		t.Fatalf("Expected a number cell but got %#v", cell)
+	}
+
+	if cell := inferredCell("000017"); cell.Type != CellString {
+		t.Fatalf("Expected a string cell keeping the leading zeros but got %#v", cell)
+	}
+
+	if cell := inferredCell("1e3"); cell.Type != CellString {
+		t.Fatalf("Expected a string cell but got %#v", cell)
+	}
+
+	if cell := inferredCell("FSM Application"); cell.Type != CellString {
+		t.Fatalf("Expected a string cell but got %#v", cell)
+	}
+}
@@ -1,14 +1,16 @@
 package spreadsheet
 
 import (
+	"math"
 	"strconv"
-
-	"github.com/addjam/fsm-processor/llog"
+	"strings"
+	"time"
 )
 
-// ColByName returns the string in the cell at the specified column
+// ColByName returns the string in the cell at the specified column.
+// Names are matched ignoring case and extra whitespace, and can be any of the column's aliases.
 func ColByName(r Row, name string) string {
-	index := indexOf(r.Headers(), name)
+	index := headerIndexFor(r).colIndex(name)
 	if index < 0 {
 		return ""
 	}
@@ -16,7 +18,8 @@ func ColByName(r Row, name string) string {
 	return r.Col(index)
 }
 
-// FloatColByName returns the float32 in the cell at the specified column
+// FloatColByName returns the float32 in the cell at the specified column. Values that
+// can't be parsed are replaced with 0 and added to the input's Report.
 func FloatColByName(r Row, name string) float32 {
 	str := ColByName(r, name)
 
@@ -25,10 +28,127 @@ func FloatColByName(r Row, name string) float32 {
 	}
 
 	value, err := strconv.ParseFloat(str, 32)
-	if err != nil {
-		llog.Printf(`Error parsing float for column "%s", falling back to 0`, name)
+	if err != nil || !isFinite(value) {
+		ReportReplacedCell(r, name, "0", ErrInvalidValue{location: r.Location(), header: name, value: str, kind: "number"})
 		return 0
 	}
 
 	return float32(value)
 }
+
+// DateColByName returns the date in the cell at the specified column. Date and number cells in xls/xlsx files
+// are used directly, otherwise the text is parsed with the first matching layout or as an excel serial date.
+func DateColByName(r Row, name string, layouts ...string) (time.Time, error) {
+	return dateCol(r, headerIndexFor(r).colIndex(name), name, layouts...)
+}
+
+// IntColByName returns the whole number in the cell at the specified column
+func IntColByName(r Row, name string) (int, error) {
+	return intCol(r, headerIndexFor(r).colIndex(name), name)
+}
+
+// MoneyColByName returns the currency amount in the cell at the specified column, e.g. "£1,234.50"
+func MoneyColByName(r Row, name string) (float32, error) {
+	return moneyCol(r, headerIndexFor(r).colIndex(name), name)
+}
+
+// CellByName returns the typed value in the cell at the specified column. Rows that don't
+// store typed values, e.g. from CSVs, return a CellString or CellEmpty.
+func CellByName(r Row, name string) Cell {
+	return cellAt(r, headerIndexFor(r).colIndex(name))
+}
+
+// dateCol returns the date in the cell at the index, name is used in errors
+func dateCol(r Row, index int, name string, layouts ...string) (time.Time, error) {
+	cell, err := requiredCell(r, index, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if cell.Type == CellDate {
+		return cell.Date, nil
+	}
+
+	var date time.Time
+	if cell.Type == CellNumber {
+		date, err = serialDate(cell.Number)
+	} else {
+		date, err = ParseDate(cell.Text, layouts...)
+	}
+	if err != nil {
+		return time.Time{}, ErrInvalidValue{location: r.Location(), header: name, value: cell.Text, kind: "date"}
+	}
+
+	return date, nil
+}
+
+// intCol returns the whole number in the cell at the index, name is used in errors
+func intCol(r Row, index int, name string) (int, error) {
+	cell, err := requiredCell(r, index, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if cell.Type == CellNumber && cell.Number == math.Trunc(cell.Number) && !math.IsInf(cell.Number, 0) {
+		return int(cell.Number), nil
+	}
+
+	number, err := ParseInt(cell.Text)
+	if err != nil {
+		return 0, ErrInvalidValue{location: r.Location(), header: name, value: cell.Text, kind: "integer"}
+	}
+
+	return number, nil
+}
+
+// moneyCol returns the currency amount in the cell at the index, name is used in errors
+func moneyCol(r Row, index int, name string) (float32, error) {
+	cell, err := requiredCell(r, index, name)
+	if err != nil {
+		return 0, err
+	}
+
+	if cell.Type == CellNumber {
+		return float32(cell.Number), nil
+	}
+
+	amount, err := ParseMoney(cell.Text)
+	if err != nil {
+		return 0, ErrInvalidValue{location: r.Location(), header: name, value: cell.Text, kind: "money"}
+	}
+
+	return amount, nil
+}
+
+// cellAt returns the typed value in the cell at the index
+func cellAt(r Row, index int) Cell {
+	if index < 0 {
+		return Cell{Type: CellEmpty}
+	}
+
+	if typed, ok := r.(TypedRow); ok {
+		return typed.Cell(index)
+	}
+
+	text := r.Col(index)
+	if strings.TrimSpace(text) == "" {
+		return Cell{Type: CellEmpty, Text: text}
+	}
+
+	return Cell{Type: CellString, Text: text}
+}
+
+// requiredCell returns the cell at the index, erroring if the column
+// is missing or the cell is empty. name is used in errors.
+func requiredCell(r Row, index int, name string) (Cell, error) {
+	if index < 0 {
+		return Cell{}, ErrMissingHeader{filePath: r.Location().Path, header: name}
+	}
+
+	cell := cellAt(r, index)
+	if cell.Type == CellEmpty || (cell.Type == CellString && strings.TrimSpace(cell.Text) == "") {
+		return Cell{}, ErrEmptyCell{location: r.Location(), header: name}
+	}
+
+	return cell, nil
+}
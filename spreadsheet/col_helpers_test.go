@@ -0,0 +1,137 @@
+package spreadsheet
+
+import (
+	"testing"
+	"time"
+)
+
+// testRow is a Row with fixed headers and values
+type testRow struct {
+	headers []string
+	values  []string
+}
+
+func (r testRow) Headers() []string { return r.headers }
+
+func (r testRow) Location() Location { return Location{Path: "test.csv", Row: 2} }
+
+func (r testRow) Col(index int) string {
+	if index < 0 || index > len(r.values)-1 {
+		return ""
+	}
+	return r.values[index]
+}
+
+func TestDateColByName(t *testing.T) {
+	row := testRow{
+		headers: []string{"Short", "Long", "Serial", "Empty", "Invalid", "Small"},
+		values:  []string{"2-Dec-18", "12/12/2018", "43446.5", "", "not a date", "12"},
+	}
+	layouts := []string{"2-Jan-06", "02/01/2006"}
+
+	t.Run("Parses using any of the layouts", func(t *testing.T) {
+		want := time.Date(2018, 12, 2, 0, 0, 0, 0, time.UTC)
+		if got, err := DateColByName(row, "Short", layouts...); err != nil || !got.Equal(want) {
+			t.Fatalf("Expected %v but got %v (%v)", want, got, err)
+		}
+
+		want = time.Date(2018, 12, 12, 0, 0, 0, 0, time.UTC)
+		if got, err := DateColByName(row, "Long", layouts...); err != nil || !got.Equal(want) {
+			t.Fatalf("Expected %v but got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("Parses excel serial dates", func(t *testing.T) {
+		want := time.Date(2018, 12, 12, 12, 0, 0, 0, time.UTC)
+		if got, err := DateColByName(row, "Serial", layouts...); err != nil || !got.Equal(want) {
+			t.Fatalf("Expected %v but got %v (%v)", want, got, err)
+		}
+	})
+
+	t.Run("Errors for empty and invalid cells", func(t *testing.T) {
+		if _, err := DateColByName(row, "Empty", layouts...); err == nil {
+			t.Fatalf("Expected an error for an empty cell")
+		}
+
+		if _, err := DateColByName(row, "Invalid", layouts...); err == nil {
+			t.Fatalf("Expected an error for an invalid cell")
+		}
+
+		if _, err := DateColByName(row, "Missing", layouts...); err == nil {
+			t.Fatalf("Expected an error for a missing column")
+		}
+
+		if got, err := DateColByName(row, "Small", layouts...); err == nil {
+			t.Fatalf("Expected an error for a number outside the range of serial dates but got %v", got)
+		}
+	})
+}
+
+func TestIntColByName(t *testing.T) {
+	row := testRow{
+		headers: []string{"Plain", "Separated", "Decimal", "Fraction", "Exponent", "Infinite"},
+		values:  []string{" 17", "1,234", "12.0", "12.5", "1e3", "Inf"},
+	}
+
+	for name, want := range map[string]int{"Plain": 17, "Separated": 1234, "Decimal": 12} {
+		if got, err := IntColByName(row, name); err != nil || got != want {
+			t.Fatalf("Expected %d but got %d (%v)", want, got, err)
+		}
+	}
+
+	for _, name := range []string{"Exponent", "Infinite"} {
+		if got, err := IntColByName(row, name); err == nil {
+			t.Fatalf("Expected an error for %s but got %d", name, got)
+		}
+	}
+
+	_, err := IntColByName(row, "Fraction")
+	if err == nil {
+		t.Fatalf("Expected an error for a fraction")
+	}
+
+	if invalid, ok := err.(ErrInvalidValue); !ok || invalid.Location() != row.Location() {
+		t.Fatalf("Expected ErrInvalidValue at %s but got %#v", row.Location(), err)
+	}
+}
+
+func TestMoneyColByName(t *testing.T) {
+	row := testRow{
+		headers: []string{"Pounds", "Plain", "Negative", "Brackets", "Invalid", "NaN", "Infinite", "Positive infinite", "Negative infinite"},
+		values:  []string{"£1,234.50", "12", "-£3.20", "(4.00)", "twelve", "NaN", "Inf", "+Inf", "-Infinity"},
+	}
+
+	for name, want := range map[string]float32{"Pounds": 1234.5, "Plain": 12, "Negative": -3.2, "Brackets": -4} {
+		if got, err := MoneyColByName(row, name); err != nil || got != want {
+			t.Fatalf("Expected %f but got %f (%v)", want, got, err)
+		}
+	}
+
+	for _, name := range []string{"Invalid", "NaN", "Infinite", "Positive infinite", "Negative infinite"} {
+		got, err := MoneyColByName(row, name)
+		if invalid, ok := err.(ErrInvalidValue); !ok || invalid.Location() != row.Location() {
+			t.Fatalf("Expected ErrInvalidValue for %s but got %f (%#v)", name, got, err)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	for _, value := range []string{"NaN", "nan", "Inf", "+Inf", "-Inf", "£Infinity", "(Inf)", "1e39"} {
+		if got, err := ParseMoney(value); err == nil {
+			t.Errorf("Expected an error for %s but got %f", value, got)
+		}
+	}
+}
+
+func TestFloatColByName(t *testing.T) {
+	row := testRow{headers: []string{"Amount", "NaN", "Infinite"}, values: []string{"12.5", "NaN", "Inf"}}
+
+	if got := FloatColByName(row, "Amount"); got != 12.5 {
+		t.Errorf("Expected 12.5 but got %f", got)
+	}
+	for _, name := range []string{"NaN", "Infinite"} {
+		if got := FloatColByName(row, name); got != 0 {
+			t.Errorf("Expected %s to be replaced with 0 but got %f", name, got)
+		}
+	}
+}
@@ -3,57 +3,87 @@ package spreadsheet
 import (
 	"bufio"
 	"encoding/csv"
-	"os"
+	"io"
 )
 
 // CsvParser is a Parser implementation that handles CSVs
 type CsvParser struct {
 	path       string
-	file       *os.File
+	closer     io.Closer
 	csvReader  *csv.Reader
+	buffered   []CsvRow // rows read while finding the header row
 	headers    []string
 	hasHeaders bool
+	aliases    HeaderAliases
+	index      headerIndex
+	policy     ParsePolicy
+	report     *Report
 }
 
 // CsvRow represents a row in a CSV file
 type CsvRow struct {
-	p    *CsvParser
-	line []string
+	p       *CsvParser
+	line    []string
+	lineNum int
 }
 
-// NewCsvParser creates a CsvParser with the given path, opening the file and preparing it for reading
+// NewCsvParser creates a CsvParser with the given input, opening the file and preparing it for reading
 func NewCsvParser(input ParserInput) (*CsvParser, error) {
-	file, err := os.Open(input.Path)
+	file, closer, err := openInput(input)
 	if err != nil {
-		return nil, ErrUnableToParse{filePath: input.Path}
+		return nil, err
 	}
 
-	fileReader := bufio.NewReader(file)
+	fileReader := bufio.NewReader(decodeText(file, input.Encoding))
 	csvReader := csv.NewReader(fileReader)
 	csvReader.LazyQuotes = true
 
-	var headers []string
-	if input.HasHeaders {
-		// Skip column header row
-		line, err := csvReader.Read()
-		if err != nil {
-			return nil, err
+	switch input.Format {
+	case Ssv:
+		csvReader.Comma = ' '
+	case Tsv:
+		csvReader.Comma = '\t'
+	case Delimited:
+		csvReader.Comma = input.Delimiter
+		if input.Delimiter == 0 {
+			csvReader.Comma = sniffDelimiter(fileReader)
 		}
-
-		headers = line
 	}
 
 	parser := &CsvParser{
 		path:       input.Path,
-		file:       file,
+		closer:     closer,
 		csvReader:  csvReader,
-		headers:    headers,
+		aliases:    input.HeaderAliases,
 		hasHeaders: input.HasHeaders,
+		policy:     input.Policy,
+		report:     input.Report,
 	}
 
-	if input.Format == Ssv {
-		parser.SetSeparator(' ')
+	if input.HasHeaders {
+		// Preamble rows above the headers can have any number of fields
+		csvReader.FieldsPerRecord = -1
+
+		firstLines, lineNums, err := parser.readLines(headerScanLength(input))
+		if err != nil {
+			closer.Close()
+			return nil, err
+		}
+
+		// Skip column header row and anything above it
+		headerRow := findHeaderRow(input, firstLines)
+		if headerRow >= len(firstLines) {
+			closer.Close()
+			return nil, ErrEOF
+		}
+
+		parser.headers = firstLines[headerRow]
+		for i := headerRow + 1; i < len(firstLines); i++ {
+			parser.buffered = append(parser.buffered, CsvRow{p: parser, line: firstLines[i], lineNum: lineNums[i]})
+		}
+		csvReader.FieldsPerRecord = len(parser.headers)
 	}
+	parser.index = newHeaderIndex(parser.headers, input.HeaderAliases)
 
 	err = AssertHeadersExist(parser, input.RequiredHeaders)
 
@@ -62,25 +92,80 @@ func NewCsvParser(input ParserInput) (*CsvParser, error) {
 
 // Next returns the next Row from the file, or errors if for example we reached the end
 func (p *CsvParser) Next() (Row, error) {
-	line, err := p.csvReader.Read()
+	for len(p.buffered) > 0 {
+		row := p.buffered[0]
+		p.buffered = p.buffered[1:]
+
+		// Rows read while finding the header row weren't checked for the number of fields by the csv reader
+		if len(row.line) != p.csvReader.FieldsPerRecord {
+			err := ErrMalformedRow{location: row.Location(), reason: csv.ErrFieldCount.Error()}
+			if skipMalformedRow(p.policy, p.report, err) {
+				continue
+			}
+			return CsvRow{}, err
+		}
 
-	if err != nil {
-		return CsvRow{}, err
+		return row, nil
+	}
+
+	for {
+		line, lineNum, err := p.readLine()
+		if skipMalformedRow(p.policy, p.report, err) {
+			continue
+		}
+
+		if err != nil {
+			return CsvRow{}, err
+		}
+
+		row := CsvRow{p: p, line: line, lineNum: lineNum}
+		return row, err
+	}
+}
+
+// readLine reads the next line and its line number, which may be the first of several if it has quoted line breaks
+func (p *CsvParser) readLine() ([]string, int, error) {
+	line, err := p.csvReader.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		location := Location{Path: p.path, Row: parseErr.StartLine}
+		return nil, 0, ErrMalformedRow{location: location, reason: parseErr.Err.Error()}
+	} else if err != nil {
+		return nil, 0, err
 	}
 
-	row := CsvRow{p: p, line: line}
-	return row, err
+	lineNum, _ := p.csvReader.FieldPos(0)
+	return line, lineNum, nil
+}
+
+// readLines reads up to n lines and their line numbers, stopping early at the end of the file.
+// Errors if the file is empty.
+func (p *CsvParser) readLines(n int) ([][]string, []int, error) {
+	lines := [][]string{}
+	lineNums := []int{}
+	for len(lines) < n {
+		line, lineNum, err := p.readLine()
+		if err == ErrEOF && len(lines) > 0 {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		lines = append(lines, line)
+		lineNums = append(lineNums, lineNum)
+	}
+	return lines, lineNums, nil
 }
 
 // Close closes the CSV file. No further operations will be possible.
 func (p CsvParser) Close() {
-	p.file.Close()
+	p.closer.Close()
 }
 
 // SetHeaderNames sets header names, allowing retrieval of columns by name
 func (p *CsvParser) SetHeaderNames(names []string) {
 	p.headers = names
 	p.hasHeaders = true
+	p.index = newHeaderIndex(names, p.aliases)
 }
 
 // SetSeparator changes the delimiter parsed in the provided file. Default is a comma.
@@ -93,6 +178,10 @@ func (p CsvParser) Headers() []string {
 	return p.headers
 }
 
+func (p CsvParser) headerIndex() headerIndex {
+	return p.index
+}
+
 // Path returns the path used for the file being parsed
 func (p CsvParser) Path() string {
 	return p.path
@@ -111,3 +200,16 @@ func (r CsvRow) Col(index int) string {
 func (r CsvRow) Headers() []string {
 	return r.p.headers
 }
+
+// Location returns the file and line the row was read from
+func (r CsvRow) Location() Location {
+	return Location{Path: r.p.path, Row: r.lineNum}
+}
+
+func (r CsvRow) headerIndex() headerIndex {
+	return r.p.index
+}
+
+func (r CsvRow) report() *Report {
+	return r.p.report
+}
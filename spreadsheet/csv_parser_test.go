@@ -53,3 +53,153 @@ func TestCsvNext(t *testing.T) {
 		AssertColumnNamed(t, row, "Date", "25/12/2019")
 	})
 }
+
+func TestCsvHeaderRow(t *testing.T) {
+	path := "./testdata/csv with preamble.txt"
+
+	t.Run("Skips rows above the given header row", func(t *testing.T) {
+		parser, err := NewCsvParser(ParserInput{Path: path, HasHeaders: true, HeaderRow: 2})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "ID", "1")
+		AssertColumnNamed(t, row, "description", "this is christmas")
+	})
+
+	t.Run("Detects the header row from the required headers", func(t *testing.T) {
+		input := ParserInput{Path: path, HasHeaders: true, DetectHeaderRows: 10, RequiredHeaders: []string{"ID", "Date"}}
+		parser, err := NewCsvParser(input)
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		rows := []Row{}
+		EachParserRow(parser, func(r Row) {
+			rows = append(rows, r)
+		})
+
+		if len(rows) != 2 {
+			t.Fatalf("Expected 2 rows but got %d", len(rows))
+		}
+
+		AssertColumnNamed(t, rows[0], "Date", "25/12/2019")
+		AssertColumnNamed(t, rows[1], "Date", "01/01/2020")
+	})
+
+	t.Run("Fails header assertions without detection", func(t *testing.T) {
+		_, err := NewCsvParser(ParserInput{Path: path, HasHeaders: true, RequiredHeaders: []string{"ID"}})
+		if _, ok := err.(ErrMissingHeader); !ok {
+			t.Fatalf("Expected ErrMissingHeader but got %#v", err)
+		}
+	})
+}
+
+func TestDelimiters(t *testing.T) {
+	t.Run("Parses tab separated files", func(t *testing.T) {
+		parser, err := NewCsvParser(ParserInput{Path: "./testdata/tab separated.txt", HasHeaders: true, Format: Tsv})
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "description", "this is, christmas")
+	})
+
+	t.Run("Parses files with a custom delimiter", func(t *testing.T) {
+		parser, err := NewCsvParser(ParserInput{Path: "./testdata/pipe separated.txt", HasHeaders: true, Format: Delimited, Delimiter: '|'})
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "description", "this is, christmas")
+	})
+
+	t.Run("Detects the delimiter of txt files", func(t *testing.T) {
+		files := []string{"./testdata/tab separated.txt", "./testdata/pipe separated.txt", "./testdata/csv with headers.txt"}
+
+		for _, path := range files {
+			parser, err := NewParser(ParserInput{Path: path, HasHeaders: true})
+			if err != nil {
+				t.Fatalf("Error creating parser for %s", path)
+			}
+
+			row, err := parser.Next()
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			AssertColumnNamed(t, row, "Date", "25/12/2019")
+		}
+	})
+}
+
+func TestEncodings(t *testing.T) {
+	files := []string{"./testdata/windows-1252.csv", "./testdata/utf-16le bom.csv", "./testdata/utf-8 bom.csv"}
+
+	for _, path := range files {
+		t.Run("Converts "+path+" to UTF-8", func(t *testing.T) {
+			parser, err := NewParser(ParserInput{Path: path, HasHeaders: true, RequiredHeaders: []string{"Claim Number"}})
+			if err != nil {
+				t.Fatalf("Error creating parser %#v", err)
+			}
+
+			row, err := parser.Next()
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			AssertColumnNamed(t, row, "Claim Number", "1001")
+			AssertColumnNamed(t, row, "Forename", "Siobhán")
+			AssertColumnNamed(t, row, "Surname", "O’Brien")
+		})
+	}
+
+	t.Run("Uses the given encoding", func(t *testing.T) {
+		parser, err := NewParser(ParserInput{Path: "./testdata/windows-1252.csv", HasHeaders: true, Encoding: Windows1252})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "Forename", "Siobhán")
+	})
+}
+
+func TestCsvLocation(t *testing.T) {
+	path := "./testdata/csv with preamble.txt"
+	parser, err := NewCsvParser(ParserInput{Path: path, HasHeaders: true, HeaderRow: 2})
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+
+	for _, line := range []int{5, 6} {
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		want := Location{Path: path, Row: line}
+		if row.Location() != want {
+			t.Errorf("Expected location %s but got %s", want, row.Location())
+		}
+	}
+}
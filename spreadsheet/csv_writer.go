@@ -0,0 +1,64 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"os"
+)
+
+// CsvWriter writes each sheet to its own CSV file. Cells are written as their Text.
+type CsvWriter struct {
+	pathForSheet func(name string) string
+	file         *os.File
+	csvWriter    *csv.Writer
+}
+
+// NewCsvWriter creates a CsvWriter, pathForSheet returns the path of the file to write each sheet to
+func NewCsvWriter(pathForSheet func(name string) string) *CsvWriter {
+	return &CsvWriter{pathForSheet: pathForSheet}
+}
+
+// AddSheet finishes the current file and creates a new one with the header row
+func (w *CsvWriter) AddSheet(name string, headers []string) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(w.pathForSheet(name))
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.csvWriter = csv.NewWriter(file)
+	return w.csvWriter.Write(headers)
+}
+
+// Write adds a row to the current file
+func (w *CsvWriter) Write(cells []Cell) error {
+	if w.csvWriter == nil {
+		return ErrNoSheet{}
+	}
+
+	return w.csvWriter.Write(cellTexts(cells))
+}
+
+// Close finishes the current file
+func (w *CsvWriter) Close() error {
+	return w.closeFile()
+}
+
+func (w *CsvWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	w.csvWriter.Flush()
+	err := w.csvWriter.Error()
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	w.file = nil
+	w.csvWriter = nil
+	return err
+}
@@ -0,0 +1,86 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"strings"
+)
+
+const (
+	// sniffBytes is the maximum amount of a file checked when detecting the delimiter
+	sniffBytes = 16 * 1024
+
+	// sniffLines is the maximum number of lines checked when detecting the delimiter
+	sniffLines = 10
+)
+
+// delimiterCandidates are the delimiters that can be detected, in order of preference
+var delimiterCandidates = []rune{',', '\t', '|', ';'}
+
+// sniffDelimiter detects the delimiter from the first lines of the reader without consuming them.
+// The delimiter that appears the same number of times on the most lines is used, defaulting to a comma.
+func sniffDelimiter(r *bufio.Reader) rune {
+	sample, _ := r.Peek(sniffBytes)
+
+	lines := strings.Split(string(sample), "\n")
+	if len(sample) == sniffBytes && len(lines) > 1 {
+		// Last line is incomplete
+		lines = lines[:len(lines)-1]
+	}
+
+	delimiter := ','
+	bestScore := 0
+	for _, candidate := range delimiterCandidates {
+		score := delimiterScore(lines, candidate)
+		if score > bestScore {
+			delimiter = candidate
+			bestScore = score
+		}
+	}
+
+	return delimiter
+}
+
+// delimiterScore returns the number of lines sharing the most common non-zero count of the delimiter
+func delimiterScore(lines []string, delimiter rune) int {
+	linesByCount := make(map[int]int)
+	checked := 0
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if count := countUnquoted(line, delimiter); count > 0 {
+			linesByCount[count]++
+		}
+
+		checked++
+		if checked == sniffLines {
+			break
+		}
+	}
+
+	score := 0
+	for _, numLines := range linesByCount {
+		if numLines > score {
+			score = numLines
+		}
+	}
+	return score
+}
+
+// countUnquoted counts how many times r appears outside of double quotes
+func countUnquoted(line string, r rune) int {
+	count := 0
+	quoted := false
+
+	for _, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == r && !quoted {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,127 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is the character encoding of a text file
+type Encoding int
+
+const (
+	// AutoEncoding detects the encoding from the byte order mark if there is one. Otherwise
+	// UTF-16 is detected from its null bytes, and text that isn't valid UTF-8 is read as Windows-1252.
+	AutoEncoding Encoding = iota
+
+	// UTF8 is UTF-8, or plain ASCII
+	UTF8
+
+	// Windows1252 is the Windows Western European code page, used by many legacy exports
+	Windows1252
+
+	// UTF16LE is little endian UTF-16
+	UTF16LE
+
+	// UTF16BE is big endian UTF-16
+	UTF16BE
+)
+
+// encodingSniffBytes is the amount of a file checked when detecting its encoding
+const encodingSniffBytes = 64 * 1024
+
+// decodeText returns a reader that transcodes r from the given encoding to UTF-8, removing any byte order mark
+func decodeText(r io.Reader, enc Encoding) io.Reader {
+	buffered := bufio.NewReaderSize(r, encodingSniffBytes)
+
+	if enc == AutoEncoding {
+		sample, _ := buffered.Peek(encodingSniffBytes)
+		enc = detectEncoding(sample)
+	}
+
+	var decoder *encoding.Decoder
+	switch enc {
+	case Windows1252:
+		decoder = charmap.Windows1252.NewDecoder()
+	case UTF16LE:
+		decoder = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	case UTF16BE:
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	default:
+		decoder = unicode.UTF8BOM.NewDecoder()
+	}
+
+	return transform.NewReader(buffered, decoder)
+}
+
+// detectEncoding guesses the encoding of text from a sample of its start
+func detectEncoding(sample []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(sample, utf8BOM):
+		return UTF8
+	case bytes.HasPrefix(sample, utf16LEBOM):
+		return UTF16LE
+	case bytes.HasPrefix(sample, utf16BEBOM):
+		return UTF16BE
+	}
+
+	if enc, ok := utf16WithoutBOM(sample); ok {
+		return enc
+	}
+
+	if validUTF8Prefix(sample) {
+		return UTF8
+	}
+
+	return Windows1252
+}
+
+// utf16WithoutBOM detects UTF-16 text without a byte order mark. Mostly ASCII text in UTF-16 has
+// a null byte in every other position, which side they're on gives the byte order.
+func utf16WithoutBOM(sample []byte) (Encoding, bool) {
+	if len(sample) < 2 {
+		return AutoEncoding, false
+	}
+
+	evenNulls, oddNulls := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+
+		if i%2 == 0 {
+			evenNulls++
+		} else {
+			oddNulls++
+		}
+	}
+
+	pairs := len(sample) / 2
+	if oddNulls > pairs/2 && evenNulls == 0 {
+		return UTF16LE, true
+	} else if evenNulls > pairs/2 && oddNulls == 0 {
+		return UTF16BE, true
+	}
+
+	return AutoEncoding, false
+}
+
+// validUTF8Prefix checks if the sample is valid UTF-8, allowing it to be cut off part way through a character
+func validUTF8Prefix(sample []byte) bool {
+	for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(sample[i]) {
+			if !utf8.FullRune(sample[i:]) {
+				sample = sample[:i]
+			}
+			break
+		}
+	}
+
+	return utf8.Valid(sample)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 var (
@@ -41,3 +42,103 @@ type ErrUnknownFormat struct {
 func (e ErrUnknownFormat) Error() string {
 	return fmt.Sprintf(`Unable to determine format of file "%s" for parsing`, e.filePath)
 }
+
+// ErrMissingSheet represents a requested sheet that isn't in the workbook
+type ErrMissingSheet struct {
+	filePath string
+	sheet    string
+}
+
+func (e ErrMissingSheet) Error() string {
+	return fmt.Sprintf(`Expected file "%s" to have sheet "%s" but it was missing`, e.filePath, e.sheet)
+}
+
+// ErrNoSheetWithHeaders is returned when no sheet in the workbook has all the required headers
+type ErrNoSheetWithHeaders struct {
+	filePath string
+	headers  []string
+}
+
+func (e ErrNoSheetWithHeaders) Error() string {
+	return fmt.Sprintf(`Expected file "%s" to have a sheet with headers "%s" but none were found`, e.filePath, strings.Join(e.headers, `", "`))
+}
+
+// ErrEmptyCell is returned when a value is expected in a cell but it's empty
+type ErrEmptyCell struct {
+	location Location
+	header   string
+}
+
+func (e ErrEmptyCell) Error() string {
+	return fmt.Sprintf(`Expected a value in column "%s" at %s but it was empty`, e.header, e.location)
+}
+
+// Location returns the location of the row with the empty cell
+func (e ErrEmptyCell) Location() Location {
+	return e.location
+}
+
+// ErrInvalidValue represents a cell value that couldn't be converted to the expected type
+type ErrInvalidValue struct {
+	location Location
+	header   string
+	value    string
+	kind     string
+}
+
+func (e ErrInvalidValue) Error() string {
+	return fmt.Sprintf(`Unable to parse %s from "%s" in column "%s" at %s`, e.kind, e.value, e.header, e.location)
+}
+
+// Location returns the location of the row with the invalid value
+func (e ErrInvalidValue) Location() Location {
+	return e.location
+}
+
+// ErrMalformedRow represents a row that couldn't be read, e.g. with the wrong number of fields
+type ErrMalformedRow struct {
+	location Location
+	reason   string
+}
+
+func (e ErrMalformedRow) Error() string {
+	return fmt.Sprintf(`Unable to read %s: %s`, e.location, e.reason)
+}
+
+// Location returns the location of the malformed row
+func (e ErrMalformedRow) Location() Location {
+	return e.location
+}
+
+// ErrLayoutMismatch is returned when a record doesn't match the RecordLayout used to parse the file
+type ErrLayoutMismatch struct {
+	location Location
+	layout   string
+	reason   string
+}
+
+func (e ErrLayoutMismatch) Error() string {
+	return fmt.Sprintf(`Record at %s doesn't match the %s layout: %s`, e.location, e.layout, e.reason)
+}
+
+// Location returns the location of the mismatched record
+func (e ErrLayoutMismatch) Location() Location {
+	return e.location
+}
+
+// ErrUnsupportedFormat represents a file in a recognised format that can't be parsed
+type ErrUnsupportedFormat struct {
+	filePath string
+	format   string
+}
+
+func (e ErrUnsupportedFormat) Error() string {
+	return fmt.Sprintf(`File "%s" is %s which can't be parsed, it should be exported as xlsx, xls or csv`, e.filePath, e.format)
+}
+
+// ErrNoSheet is returned when writing a row before a sheet has been added
+type ErrNoSheet struct{}
+
+func (e ErrNoSheet) Error() string {
+	return "A sheet must be added before writing rows"
+}
@@ -0,0 +1,100 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+)
+
+// formatSniffBytes is the amount of the start of a file used to detect its format
+const formatSniffBytes = 512
+
+var (
+	ole2Magic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	zipMagic  = []byte("PK\x03\x04")
+
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// detectFormat sets the Format of Auto inputs from their content, using the extension to decide
+// between the text formats. As the content is peeked the returned input must be used in place of
+// the original.
+func detectFormat(input ParserInput) (ParserInput, error) {
+	if input.Format != Auto {
+		return input, nil
+	}
+
+	head, input, err := peekInput(input, formatSniffBytes)
+	if err != nil {
+		return input, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, ole2Magic):
+		input.Format = Xls
+	case bytes.HasPrefix(head, zipMagic):
+		input, err = bufferInput(input)
+		if err != nil {
+			return input, err
+		}
+
+		if !isXlsxWorkbook(input) {
+			return input, ErrUnknownFormat{input.Path}
+		}
+		input.Format = Xlsx
+	case isMarkup(head):
+		return input, ErrUnsupportedFormat{filePath: input.Path, format: "HTML or XML"}
+	case !isText(head) && input.Encoding != UTF16LE && input.Encoding != UTF16BE:
+		return input, ErrUnknownFormat{input.Path}
+	default:
+		input.Format = formatFromExtension(filepath.Ext(input.Path))
+		if input.Format == Xls || input.Format == Xlsx {
+			// Text exported with a spreadsheet extension, e.g. a CSV saved as .xls
+			input.Format = Delimited
+		}
+	}
+
+	return input, nil
+}
+
+// isXlsxWorkbook checks if the input is a zip containing an xlsx workbook, rather than e.g. a docx
+func isXlsxWorkbook(input ParserInput) bool {
+	reader, closer, err := openSizedInput(input)
+	if err != nil {
+		return false
+	}
+	defer closer.Close()
+
+	zipReader, err := zip.NewReader(reader, reader.Size())
+	if err != nil {
+		return false
+	}
+
+	for _, f := range zipReader.File {
+		if f.Name == "xl/workbook.xml" {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarkup checks if the content is HTML or XML, e.g. a web page or xml spreadsheet saved with an xls extension
+func isMarkup(head []byte) bool {
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, utf8BOM), " \t\r\n")
+	return bytes.HasPrefix(head, []byte("<"))
+}
+
+// isText checks if the content is text rather than an unrecognised binary format
+func isText(head []byte) bool {
+	if bytes.HasPrefix(head, utf16LEBOM) || bytes.HasPrefix(head, utf16BEBOM) {
+		return true
+	}
+
+	if _, ok := utf16WithoutBOM(head); ok {
+		return true
+	}
+
+	return bytes.IndexByte(head, 0) < 0
+}
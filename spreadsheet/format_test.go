@@ -0,0 +1,108 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	xlsData, _ := ioutil.ReadFile("./testdata/Consent Report W360.xls")
+	xlsxData, _ := ioutil.ReadFile("./testdata/Consent Report W360.xlsx")
+
+	var docx bytes.Buffer
+	zipWriter := zip.NewWriter(&docx)
+	zipWriter.Create("word/document.xml")
+	zipWriter.Close()
+
+	// A reader that's already been read past a preamble
+	advanced := bytes.NewReader(append([]byte("preamble\n"), xlsxData...))
+	advanced.Seek(int64(len("preamble\n")), io.SeekStart)
+
+	formats := []struct {
+		name   string
+		input  ParserInput
+		format Format
+	}{
+		{"xls named xlsx", ParserInput{Path: "report.xlsx", ReaderAt: bytes.NewReader(xlsData), Size: int64(len(xlsData))}, Xls},
+		{"xlsx named xls", ParserInput{Path: "report.xls", Reader: onlyReader{bytes.NewReader(xlsxData)}}, Xlsx},
+		{"csv named xls", ParserInput{Path: "report.xls", Reader: strings.NewReader("a,b\n1,2\n")}, Delimited},
+		{"csv", ParserInput{Path: "report.csv", Reader: strings.NewReader("a,b\n1,2\n")}, Csv},
+		{"xlsx from the reader's position", ParserInput{Path: "report.txt", Reader: advanced}, Xlsx},
+		{"text file", ParserInput{Path: "./testdata/tab separated.txt"}, Delimited},
+		{"UTF-16 text", ParserInput{Path: "report.tsv", Reader: bytes.NewReader([]byte{0xFF, 0xFE, 'a', 0, '\t', 0, 'b', 0})}, Tsv},
+	}
+
+	for _, tt := range formats {
+		t.Run("Detects "+tt.name, func(t *testing.T) {
+			input, err := detectFormat(tt.input)
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			if input.Format != tt.format {
+				t.Errorf("Expected format %d but got %d", tt.format, input.Format)
+			}
+		})
+	}
+
+	t.Run("Parses an xlsx named xls", func(t *testing.T) {
+		parser, err := NewParser(ParserInput{Path: "report.xls", Reader: bytes.NewReader(xlsxData), HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "DocDesc", "FSM Application")
+	})
+
+	t.Run("Parses an xlsx from the reader's position", func(t *testing.T) {
+		reader := bytes.NewReader(append([]byte("preamble\n"), xlsxData...))
+		reader.Seek(int64(len("preamble\n")), io.SeekStart)
+
+		parser, err := NewParser(ParserInput{Path: "report.txt", Reader: reader, HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "DocDesc", "FSM Application")
+	})
+
+	t.Run("Errors for html saved as xls", func(t *testing.T) {
+		_, err := NewParser(ParserInput{Path: "report.xls", Reader: strings.NewReader("\n<html><table></table></html>")})
+
+		if _, ok := err.(ErrUnsupportedFormat); !ok {
+			t.Errorf("Expected ErrUnsupportedFormat but got %#v", err)
+		}
+	})
+
+	unknown := map[string][]byte{
+		"a zip that isn't a workbook": docx.Bytes(),
+		"binary data":                 {0x89, 'P', 'N', 'G', 0, 0, 0, 0},
+	}
+
+	for name, data := range unknown {
+		t.Run("Errors for "+name, func(t *testing.T) {
+			parser, err := NewParser(ParserInput{Path: "report.xlsx", Reader: bytes.NewReader(data)})
+
+			if _, ok := err.(ErrUnknownFormat); !ok {
+				t.Errorf("Expected ErrUnknownFormat but got %#v", err)
+			}
+			if parser != nil {
+				t.Errorf("Expected no parser but got %#v", parser)
+			}
+		})
+	}
+}
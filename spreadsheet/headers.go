@@ -0,0 +1,90 @@
+package spreadsheet
+
+import (
+	"sort"
+	"strings"
+)
+
+// HeaderAliases maps a column name to the other labels that column has been exported with,
+// e.g. by a different version of the report. Columns can be fetched by name with any of the labels.
+type HeaderAliases map[string][]string
+
+// headerIndex maps normalised header names to the index of their column
+type headerIndex map[string]int
+
+// indexedHeaders is implemented by parsers and rows with a precomputed headerIndex
+type indexedHeaders interface {
+	headerIndex() headerIndex
+}
+
+// newHeaderIndex creates a headerIndex for the headers. Where headers are repeated the first is used.
+// Each name in aliases and all of its alternatives are mapped to the same column, whichever label the
+// file uses, though a label that's a header of its own in the file keeps its own column.
+func newHeaderIndex(headers []string, aliases HeaderAliases) headerIndex {
+	index := make(headerIndex, len(headers))
+	for i, header := range headers {
+		key := normaliseHeader(header)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Columns are resolved against the headers alone, so the result doesn't depend on the order of the aliases
+	resolved := make(map[string]int, len(names))
+	for _, name := range names {
+		labels := append([]string{name}, aliases[name]...)
+		for _, label := range labels {
+			if i, ok := index[normaliseHeader(label)]; ok {
+				resolved[name] = i
+				break
+			}
+		}
+	}
+
+	for _, name := range names {
+		i, ok := resolved[name]
+		if !ok {
+			continue
+		}
+
+		labels := append([]string{name}, aliases[name]...)
+		for _, label := range labels {
+			key := normaliseHeader(label)
+			if _, exists := index[key]; !exists {
+				index[key] = i
+			}
+		}
+	}
+
+	return index
+}
+
+// colIndex returns the index of the column with the given name, or -1 if it doesn't exist
+func (h headerIndex) colIndex(name string) int {
+	if i, ok := h[normaliseHeader(name)]; ok {
+		return i
+	}
+
+	return -1
+}
+
+// headerIndexFor returns the precomputed headerIndex if available, otherwise creates one
+func headerIndexFor(h interface{ Headers() []string }) headerIndex {
+	if indexed, ok := h.(indexedHeaders); ok {
+		return indexed.headerIndex()
+	}
+
+	return newHeaderIndex(h.Headers(), nil)
+}
+
+// normaliseHeader lowercases the header and collapses whitespace, so
+// e.g. "PostCode " and "Postcode" are treated as the same header
+func normaliseHeader(header string) string {
+	return strings.ToLower(strings.Join(strings.Fields(header), " "))
+}
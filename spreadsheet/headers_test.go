@@ -0,0 +1,60 @@
+package spreadsheet
+
+import "testing"
+
+func TestHeaderMatching(t *testing.T) {
+	t.Run("Ignores case and whitespace in column names", func(t *testing.T) {
+		parser, err := NewCsvParser(ParserInput{Path: "./testdata/csv with headers.txt", HasHeaders: true, RequiredHeaders: []string{" id", "DESCRIPTION"}})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "Id ", "1")
+		AssertColumnNamed(t, row, "DESCRIPTION", "this is christmas")
+		AssertColumnNamed(t, row, "date", "25/12/2019")
+	})
+
+	t.Run("Finds columns by alias", func(t *testing.T) {
+		input := ParserInput{
+			Path:            "./testdata/csv with headers.txt",
+			HasHeaders:      true,
+			HeaderAliases:   HeaderAliases{"Claim  Number": {"Claim Ref", "ID"}},
+			RequiredHeaders: []string{"Claim Number"},
+		}
+		parser, err := NewCsvParser(input)
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "claim number", "1")
+	})
+
+	t.Run("Finds columns by alias when the file uses the canonical header", func(t *testing.T) {
+		index := newHeaderIndex([]string{"Claim Number", "Forename"}, HeaderAliases{"Claim Number": {"Claim Ref"}})
+
+		if got := index.colIndex("claim ref"); got != 0 {
+			t.Fatalf("Expected 0 but got %d", got)
+		}
+	})
+
+	t.Run("Uses the first of repeated headers", func(t *testing.T) {
+		index := newHeaderIndex([]string{"a", "b", "A "}, nil)
+		if got := index.colIndex("a"); got != 0 {
+			t.Fatalf("Expected 0 but got %d", got)
+		}
+
+		if got := index.colIndex("c"); got != -1 {
+			t.Fatalf("Expected -1 but got %d", got)
+		}
+	})
+}
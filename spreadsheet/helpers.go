@@ -1,25 +1,29 @@
 package spreadsheet
 
-import (
-	"encoding/csv"
-)
+import "context"
 
 // EachParserRow calls func for each of the rows provided by a Parser
 // Automatically closes the parser
 func EachParserRow(p Parser, f func(Row)) error {
+	return eachParserRow(nil, p, f)
+}
+
+// eachParserRow calls func for each of the rows provided by a Parser until the context is done,
+// returning its error. The context is optional. Automatically closes the parser.
+func eachParserRow(ctx context.Context, p Parser, f func(Row)) error {
 	defer p.Close()
 
 	for {
+		err := contextErr(ctx)
+		if err != nil {
+			return err
+		}
+
 		row, err := p.Next()
 
 		if err == ErrEOF {
 			break
 		} else if err != nil {
-			if err, ok := err.(*csv.ParseError); ok && err.Err == csv.ErrFieldCount {
-				// Some files have extra data which presents as a field count error, e.g. hb-uc.d has some trailing data.
-				break
-			}
-
 			return err
 		}
 
@@ -36,7 +40,28 @@ func EachRow(input ParserInput, f func(Row)) error {
 		return err
 	}
 
-	return EachParserRow(parser, f)
+	// Rows shared by a Registry have already been counted when it parsed them
+	if input.Progress == nil || input.Registry.shares(input) {
+		return eachParserRow(input.Context, parser, f)
+	}
+
+	counter := progressCounter{progress: input.Progress}
+	err = eachParserRow(input.Context, parser, func(r Row) {
+		counter.add()
+		f(r)
+	})
+	if err == nil {
+		counter.done()
+	}
+	return err
+}
+
+// contextErr returns the error of the context once it's cancelled or its deadline has passed, nil if ctx is nil
+func contextErr(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Err()
 }
 
 // CountRows returns the number of rows in a spreadsheet
@@ -63,8 +88,9 @@ func CreateIndex(i ParserInput, colName string, rowKeyCreator func(string) strin
 
 // AssertHeadersExist ensures the provided headers exist and exits if they don't
 func AssertHeadersExist(p Parser, expectedHeaders []string) error {
+	index := headerIndexFor(p)
 	for _, hdr := range expectedHeaders {
-		if indexOf(p.Headers(), hdr) < 0 {
+		if index.colIndex(hdr) < 0 {
 			return ErrMissingHeader{filePath: p.Path(), header: hdr}
 		}
 	}
@@ -72,6 +98,49 @@ func AssertHeadersExist(p Parser, expectedHeaders []string) error {
 	return nil
 }
 
+// headerScanLength returns how many rows need to be read to find the header row
+func headerScanLength(input ParserInput) int {
+	if input.DetectHeaderRows > input.HeaderRow+1 {
+		return input.DetectHeaderRows
+	}
+
+	return input.HeaderRow + 1
+}
+
+// findHeaderRow returns the index of the header row within the first rows of a sheet.
+// When detecting, this is the earliest row with the most RequiredHeaders, otherwise HeaderRow.
+func findHeaderRow(input ParserInput, rows [][]string) int {
+	headerRow := input.HeaderRow
+	bestMatches := 0
+
+	for i := 0; i < input.DetectHeaderRows && i < len(rows); i++ {
+		index := newHeaderIndex(rows[i], input.HeaderAliases)
+		matches := 0
+		for _, hdr := range input.RequiredHeaders {
+			if index.colIndex(hdr) >= 0 {
+				matches++
+			}
+		}
+
+		if matches > bestMatches {
+			headerRow = i
+			bestMatches = matches
+		}
+	}
+
+	return headerRow
+}
+
+// headerRowValues returns the cells from the header row within the first rows of a sheet
+func headerRowValues(input ParserInput, rows [][]string) []string {
+	headerRow := findHeaderRow(input, rows)
+	if headerRow < 0 || headerRow >= len(rows) {
+		return []string{}
+	}
+
+	return rows[headerRow]
+}
+
 func indexOf(in []string, target string) int {
 	for index, header := range in {
 		if header == target {
@@ -80,3 +149,27 @@ func indexOf(in []string, target string) int {
 	}
 	return -1
 }
+
+// ProgressInterval is the number of rows parsed between calls to the Progress func of an input
+const ProgressInterval = 1000
+
+// progressCounter counts the rows parsed from an input, reporting them to its Progress func
+type progressCounter struct {
+	progress func(rows int, done bool)
+	rows     int
+}
+
+// add counts a row, reporting the count every ProgressInterval rows
+func (c *progressCounter) add() {
+	c.rows++
+	if c.progress != nil && c.rows%ProgressInterval == 0 {
+		c.progress(c.rows, false)
+	}
+}
+
+// done reports the final count
+func (c *progressCounter) done() {
+	if c.progress != nil {
+		c.progress(c.rows, true)
+	}
+}
@@ -0,0 +1,143 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// sizedReader is a seekable input with a known size, as needed to parse xls and xlsx files
+type sizedReader interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	Size() int64
+}
+
+// nopCloser is returned as the closer of inputs owned by the caller
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// openInput returns a reader for the input's ReaderAt, Reader or Path, in that order of priority.
+// The closer closes the file opened for a Path, readers given by the caller are left open.
+func openInput(input ParserInput) (io.Reader, io.Closer, error) {
+	if input.ReaderAt != nil {
+		return io.NewSectionReader(input.ReaderAt, 0, input.Size), nopCloser{}, nil
+	}
+
+	if input.Reader != nil {
+		return input.Reader, nopCloser{}, nil
+	}
+
+	file, err := os.Open(input.Path)
+	if err != nil {
+		return nil, nil, ErrUnableToParse{filePath: input.Path}
+	}
+
+	return file, file, nil
+}
+
+// openSizedInput is like openInput but returns a sizedReader. Readers that
+// can't seek are read into memory, those that can are read from their current position.
+func openSizedInput(input ParserInput) (sizedReader, io.Closer, error) {
+	r, closer, err := openInput(input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch r := r.(type) {
+	case sizedReader:
+		position, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, nil, ErrUnableToParse{filePath: input.Path}
+		}
+		if position > 0 {
+			return io.NewSectionReader(r, position, r.Size()-position), closer, nil
+		}
+		return r, closer, nil
+	case *os.File:
+		info, err := r.Stat()
+		if err == nil {
+			return io.NewSectionReader(r, 0, info.Size()), closer, nil
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	closer.Close()
+	if err != nil {
+		return nil, nil, ErrUnableToParse{filePath: input.Path}
+	}
+
+	return bytes.NewReader(data), nopCloser{}, nil
+}
+
+// peekInput returns up to the first n bytes of the input without consuming them, from the current position
+// of a Reader. Readers are wrapped in a buffer so the returned input must be used in place of the original.
+func peekInput(input ParserInput, n int) ([]byte, ParserInput, error) {
+	head := make([]byte, n)
+
+	if input.ReaderAt != nil {
+		read, err := input.ReaderAt.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			return nil, input, ErrUnableToParse{filePath: input.Path}
+		}
+		return head[:read], input, nil
+	}
+
+	if input.Reader != nil {
+		if r, ok := input.Reader.(sizedReader); ok {
+			position, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, input, ErrUnableToParse{filePath: input.Path}
+			}
+
+			read, err := r.ReadAt(head, position)
+			if err != nil && err != io.EOF {
+				return nil, input, ErrUnableToParse{filePath: input.Path}
+			}
+			return head[:read], input, nil
+		}
+
+		buffered := bufio.NewReaderSize(input.Reader, n)
+		input.Reader = buffered
+		peeked, err := buffered.Peek(n)
+		if err != nil && err != io.EOF {
+			return nil, input, ErrUnableToParse{filePath: input.Path}
+		}
+		return peeked, input, nil
+	}
+
+	file, err := os.Open(input.Path)
+	if err != nil {
+		return nil, input, ErrUnableToParse{filePath: input.Path}
+	}
+	defer file.Close()
+
+	read, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, input, ErrUnableToParse{filePath: input.Path}
+	}
+	return head[:read], input, nil
+}
+
+// bufferInput reads a Reader that can't seek into memory, so it can be read more than once
+func bufferInput(input ParserInput) (ParserInput, error) {
+	if input.ReaderAt != nil || input.Reader == nil {
+		return input, nil
+	}
+
+	reader, _, err := openSizedInput(input)
+	if err != nil {
+		return input, err
+	}
+
+	input.Reader = nil
+	input.ReaderAt = reader
+	input.Size = reader.Size()
+	return input, nil
+}
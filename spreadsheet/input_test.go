@@ -0,0 +1,79 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// onlyReader hides any other interfaces implemented by the reader, like a network stream
+type onlyReader struct {
+	io.Reader
+}
+
+func TestReaderInput(t *testing.T) {
+	t.Run("Parses CSV from a reader", func(t *testing.T) {
+		reader := strings.NewReader("ID,description,Date\n1,this is christmas,25/12/2019\n")
+		parser, err := NewParser(ParserInput{Reader: onlyReader{reader}, Format: Csv, HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "Date", "25/12/2019")
+	})
+
+	t.Run("Detects the format from the path of a reader", func(t *testing.T) {
+		reader := strings.NewReader("ID\tdescription\tDate\n1\tthis is christmas\t25/12/2019\n")
+		parser, err := NewParser(ParserInput{Reader: reader, Path: "upload.tsv", HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "description", "this is christmas")
+	})
+
+	for _, path := range []string{"./testdata/Consent Report W360.xls", "./testdata/Consent Report W360.xlsx"} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Error reading %s", path)
+		}
+
+		inputs := map[string]ParserInput{
+			"reader":          {Reader: onlyReader{bytes.NewReader(data)}},
+			"seekable reader": {Reader: bytes.NewReader(data)},
+			"reader at":       {ReaderAt: bytes.NewReader(data), Size: int64(len(data))},
+		}
+
+		for name, input := range inputs {
+			t.Run("Parses "+path+" from a "+name, func(t *testing.T) {
+				input.Path = path
+				input.HasHeaders = true
+
+				parser, err := NewParser(input)
+				if err != nil {
+					t.Fatalf("Error creating parser %#v", err)
+				}
+				defer parser.Close()
+
+				row, err := parser.Next()
+				if err != nil {
+					t.Fatalf("Got an unexpected error %#v", err)
+				}
+
+				AssertColumnNamed(t, row, "DocDesc", "FSM Application")
+			})
+		}
+	}
+}
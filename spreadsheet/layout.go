@@ -0,0 +1,140 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldType is the type of value expected in a field of a RecordLayout
+type FieldType int
+
+const (
+	// FieldText accepts any value
+	FieldText FieldType = iota
+
+	// FieldInt is a whole number
+	FieldInt
+
+	// FieldMoney is a currency amount
+	FieldMoney
+
+	// FieldDate is a date matching one of the field's DateLayouts
+	FieldDate
+)
+
+// Field is a named field within a record
+type Field struct {
+	Name string
+	Type FieldType
+
+	// Optional allows the field to be empty, otherwise typed fields must have a value
+	Optional bool
+
+	// DateLayouts are the time layouts accepted by FieldDate fields
+	DateLayouts []string
+
+	// Start and Width locate the field in FixedWidth records, in characters from the start of the line (the first is 0)
+	Start int
+	Width int
+}
+
+// RecordLayout describes the records in a text file without headers, e.g. a DWP data extract.
+// Records are checked against the layout as they're read so a change to the file's layout is
+// reported rather than the wrong fields being used.
+type RecordLayout struct {
+	// Name identifies the layout in errors
+	Name string
+
+	Fields []Field
+
+	// FixedWidth locates fields by their Start and Width, otherwise records are split on the Delimiter
+	FixedWidth bool
+
+	// Delimiter separates fields when the layout isn't FixedWidth. Defaults to a space.
+	Delimiter rune
+
+	// Trailer allows the file to end with records that don't match the layout, e.g. totals. They're skipped.
+	Trailer bool
+}
+
+// fieldNames returns the name of each field, used as the headers of a RecordParser
+func (l RecordLayout) fieldNames() []string {
+	names := make([]string, len(l.Fields))
+	for i, field := range l.Fields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+func (l RecordLayout) delimiter() rune {
+	if l.Delimiter == 0 {
+		return ' '
+	}
+	return l.Delimiter
+}
+
+// split separates a fixed width line into the values of each field
+func (l RecordLayout) split(line string) ([]string, error) {
+	lineWidth := utf8.RuneCountInString(line)
+	runes := []rune(line)
+
+	values := make([]string, len(l.Fields))
+	for i, field := range l.Fields {
+		end := field.Start + field.Width
+		if end > lineWidth {
+			return nil, fmt.Errorf("expected at least %d characters but found %d", end, lineWidth)
+		}
+		values[i] = strings.TrimSpace(string(runes[field.Start:end]))
+	}
+
+	return values, nil
+}
+
+// check returns an error describing how the values don't match the layout, or nil if they do
+func (l RecordLayout) check(values []string) error {
+	if len(values) != len(l.Fields) {
+		return fmt.Errorf("expected %d fields but found %d", len(l.Fields), len(values))
+	}
+
+	for i, field := range l.Fields {
+		if _, err := field.cell(values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cell converts the value of the field to a typed Cell, erroring if it's not valid for the field's type
+func (f Field) cell(value string) (Cell, error) {
+	if strings.TrimSpace(value) == "" {
+		if f.Type != FieldText && !f.Optional {
+			return Cell{}, fmt.Errorf(`field "%s" is empty`, f.Name)
+		}
+		return Cell{Type: CellEmpty, Text: value}, nil
+	}
+
+	switch f.Type {
+	case FieldInt:
+		number, err := ParseInt(value)
+		if err != nil {
+			return Cell{}, fmt.Errorf(`field "%s" should be a whole number but was "%s"`, f.Name, value)
+		}
+		return Cell{Type: CellNumber, Text: value, Number: float64(number)}, nil
+	case FieldMoney:
+		amount, err := ParseMoney(value)
+		if err != nil {
+			return Cell{}, fmt.Errorf(`field "%s" should be an amount of money but was "%s"`, f.Name, value)
+		}
+		return Cell{Type: CellNumber, Text: value, Number: float64(amount)}, nil
+	case FieldDate:
+		date, err := ParseDate(value, f.DateLayouts...)
+		if err != nil {
+			return Cell{}, fmt.Errorf(`field "%s" should be a date but was "%s"`, f.Name, value)
+		}
+		return Cell{Type: CellDate, Text: value, Date: date}, nil
+	}
+
+	return Cell{Type: CellString, Text: value}, nil
+}
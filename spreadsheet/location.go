@@ -0,0 +1,18 @@
+package spreadsheet
+
+import "fmt"
+
+// Location is where a row was read from
+type Location struct {
+	Path  string `json:"path"`
+	Sheet string `json:"sheet,omitempty"` // empty for text files
+	Row   int    `json:"row"`             // 1-based row or line number, as shown in excel or a text editor
+}
+
+func (l Location) String() string {
+	if l.Sheet != "" {
+		return fmt.Sprintf(`"%s" sheet "%s" row %d`, l.Path, l.Sheet, l.Row)
+	}
+
+	return fmt.Sprintf(`"%s" line %d`, l.Path, l.Row)
+}
@@ -1,7 +1,8 @@
 package spreadsheet
 
 import (
-	"path/filepath"
+	"context"
+	"io"
 )
 
 // Parser is an interface for types that can parse a spreadsheet by Row
@@ -17,13 +18,15 @@ type Parser interface {
 type Row interface {
 	Headers() []string
 	Col(int) string
+	Location() Location
 }
 
 // Format represents the format of the spreadsheete, e.g. xls, csv, etc
 type Format int
 
 const (
-	// Auto to auto-detect the format. Based on extension and supports xls, xlsx and falls back to csv
+	// Auto to auto-detect the format. Based on the file's content, supports xls, xlsx and text files.
+	// The extension is used to pick the text format, falling back to detecting the delimiter.
 	Auto = iota
 
 	// Csv is CSV format
@@ -37,40 +40,119 @@ const (
 
 	// Xlsx is a modern xlsx excel file
 	Xlsx = iota
+
+	// Tsv is tab separated
+	Tsv = iota
+
+	// Delimited is separated by ParserInput.Delimiter, or if that's not set the delimiter is detected
+	// from the first few lines of the file. Supports commas, tabs, pipes and semicolons.
+	Delimited = iota
 )
 
 //ParserInput represents a spreadsheet and associated options/validations
 type ParserInput struct {
-	Path            string
+	// Path of the file to parse. When reading from Reader or ReaderAt it's optional, and
+	// only used to detect the format by its extension and to identify the input in errors.
+	Path string
+
+	// Reader is parsed instead of opening Path, e.g. stdin or an upload. xls and xlsx files are
+	// read into memory unless it's also an io.ReaderAt and io.Seeker with a Size method, like bytes.Reader.
+	// Readers aren't closed by the parser.
+	Reader io.Reader
+
+	// ReaderAt is parsed instead of opening Path or reading Reader, Size must also be set
+	ReaderAt io.ReaderAt
+	Size     int64
+
 	HasHeaders      bool
 	RequiredHeaders []string
 	Format          Format
+
+	// Delimiter separates fields in Delimited files
+	Delimiter rune
+
+	// Encoding is the character encoding of text files, which are converted to UTF-8 when read
+	Encoding Encoding
+
+	// HeaderAliases lists other labels a column may have, so it can be found using a single name
+	HeaderAliases HeaderAliases
+
+	// HeaderRow is the index of the row containing the headers when HasHeaders is true, the first row is 0.
+	// Any rows above it, e.g. report titles or dates, are skipped.
+	HeaderRow int
+
+	// DetectHeaderRows scans up to this many rows for the one matching the most RequiredHeaders and
+	// uses it as the header row. HeaderRow is used if no rows match. 0 disables detection.
+	DetectHeaderRows int
+
+	// SheetName selects the sheet of an xls/xlsx workbook to parse by name, takes priority over SheetIndex
+	SheetName string
+
+	// SheetIndex selects the sheet of an xls/xlsx workbook to parse by position, the first sheet is 0
+	SheetIndex int
+
+	// FindSheetByHeaders parses the first sheet that has all of the RequiredHeaders in its header row, ignoring SheetName and SheetIndex
+	FindSheetByHeaders bool
+
+	// Layout parses a text file without headers as records with the layout's fields, ignoring Format and the header options
+	Layout *RecordLayout
+
+	// Policy decides if malformed rows fail parsing or are skipped
+	Policy ParsePolicy
+
+	// Report collects skipped rows and replaced cells, optional
+	Report *Report
+
+	// Stream reads xlsx rows directly from the file as they're needed rather than
+	// loading the whole workbook into memory up front. Useful for very large sheets.
+	Stream bool
+
+	// Registry shares the rows of an input that's read more than once, parsing the file only
+	// the first time, optional. Inputs read from Reader or ReaderAt are parsed each time.
+	// Report, Progress and Context apply to the first parse.
+	Registry *Registry
+
+	// Progress is called with the number of rows parsed so far by EachRow, or by a Registry the first
+	// time the input is read, every ProgressInterval rows and once parsing stops with done. Optional.
+	Progress func(rows int, done bool)
+
+	// Context stops EachRow, or a Registry parsing the input, with its error once it's cancelled or its deadline has passed. Optional.
+	Context context.Context
 }
 
 // NewParser creates a parser appropriate for the spreadsheet at the given path.
 // Supports:
 //   - CSV
+//   - TSV, and text files with other delimiters
 //   - xls
 //   - xlsx
+//   - text files with a RecordLayout
+//
+// When the input has a Registry the parser reads the rows it shares.
 func NewParser(input ParserInput) (Parser, error) {
-	inputFormat := input.Format
-	if inputFormat == Auto {
-		extension := filepath.Ext(input.Path)
-		inputFormat = formatFromExtension(extension)
+	if input.Registry.shares(input) {
+		return input.Registry.parser(input)
 	}
 
-	switch inputFormat {
+	if input.Layout != nil {
+		return NewRecordParser(input)
+	}
+
+	input, err := detectFormat(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch input.Format {
 	case Xls:
 		return NewXlsParser(input)
 	case Xlsx:
 		return NewXlsxParser(input)
-	case Csv:
-		return NewCsvParser(input)
-	case Ssv:
+	case Csv, Ssv, Tsv, Delimited:
 		return NewCsvParser(input)
 	}
 
-	return nil, nil
+	return nil, ErrUnknownFormat{input.Path}
 }
 
 func formatFromExtension(ext string) Format {
@@ -80,10 +162,12 @@ func formatFromExtension(ext string) Format {
 	case ".xlsx":
 		return Xlsx
 	case ".txt":
-		return Csv
+		return Delimited
+	case ".tsv":
+		return Tsv
 	case ".csv":
 		return Csv
 	}
 
-	return Csv
+	return Delimited
 }
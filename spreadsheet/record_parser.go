@@ -0,0 +1,229 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// RecordParser is a Parser implementation for text files without headers
+// whose records follow a RecordLayout
+type RecordParser struct {
+	path      string
+	closer    io.Closer
+	layout    RecordLayout
+	csvReader *csv.Reader    // reads delimited records
+	scanner   *bufio.Scanner // reads fixed width records
+	lineNum   int
+	headers   []string
+	index     headerIndex
+	policy    ParsePolicy
+	report    *Report
+}
+
+// RecordRow represents a record in a file parsed by a RecordParser
+type RecordRow struct {
+	p       *RecordParser
+	values  []string
+	lineNum int
+}
+
+// NewRecordParser creates a RecordParser for input.Layout, opening the input and preparing it for reading
+func NewRecordParser(input ParserInput) (*RecordParser, error) {
+	file, closer, err := openInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := *input.Layout
+	parser := &RecordParser{
+		path:    input.Path,
+		closer:  closer,
+		layout:  layout,
+		headers: layout.fieldNames(),
+		policy:  input.Policy,
+		report:  input.Report,
+	}
+	parser.index = newHeaderIndex(parser.headers, input.HeaderAliases)
+
+	text := decodeText(file, input.Encoding)
+	if layout.FixedWidth {
+		parser.scanner = bufio.NewScanner(text)
+	} else {
+		parser.csvReader = csv.NewReader(bufio.NewReader(text))
+		parser.csvReader.Comma = layout.delimiter()
+		parser.csvReader.LazyQuotes = true
+		parser.csvReader.FieldsPerRecord = -1
+	}
+
+	err = AssertHeadersExist(parser, input.RequiredHeaders)
+
+	return parser, err
+}
+
+// layoutRecord is a record read by a RecordParser, with a description of how it doesn't match the layout if it doesn't
+type layoutRecord struct {
+	values   []string
+	lineNum  int
+	mismatch error
+}
+
+// Next returns the next record from the file. Errors with ErrLayoutMismatch if the record doesn't
+// match the layout, unless it's part of the layout's trailer or is skipped by the Lenient policy.
+func (p *RecordParser) Next() (Row, error) {
+	for {
+		record, err := p.nextRecord()
+		if skipMalformedRow(p.policy, p.report, err) {
+			continue
+		} else if err != nil {
+			return RecordRow{}, err
+		}
+
+		if record.mismatch == nil {
+			return RecordRow{p: p, values: record.values, lineNum: record.lineNum}, nil
+		}
+
+		mismatches := []error{p.mismatchErr(record)}
+		if p.layout.Trailer {
+			record, mismatches, err = p.readTrailer(mismatches)
+			if err != nil {
+				return RecordRow{}, err
+			}
+		}
+
+		for _, mismatch := range mismatches {
+			if !skipMalformedRow(p.policy, p.report, mismatch) {
+				return RecordRow{}, mismatch
+			}
+		}
+
+		if record.mismatch == nil {
+			// The mismatched records weren't a trailer, and have been skipped
+			return RecordRow{p: p, values: record.values, lineNum: record.lineNum}, nil
+		}
+	}
+}
+
+// readTrailer reads on from mismatched records until one matches the layout, returning it along with the
+// errors for all of the mismatched records. Errors with ErrEOF if the end of the file is reached first,
+// as the mismatched records were the trailer.
+func (p *RecordParser) readTrailer(mismatches []error) (layoutRecord, []error, error) {
+	for {
+		record, err := p.nextRecord()
+		if _, ok := err.(ErrMalformedRow); ok {
+			mismatches = append(mismatches, err)
+		} else if err != nil {
+			return layoutRecord{}, mismatches, err
+		} else if record.mismatch != nil {
+			mismatches = append(mismatches, p.mismatchErr(record))
+		} else {
+			return record, mismatches, nil
+		}
+	}
+}
+
+func (p *RecordParser) mismatchErr(record layoutRecord) error {
+	location := Location{Path: p.path, Row: record.lineNum}
+	return ErrLayoutMismatch{location: location, layout: p.layout.Name, reason: record.mismatch.Error()}
+}
+
+func (p *RecordParser) nextRecord() (layoutRecord, error) {
+	if !p.layout.FixedWidth {
+		values, err := p.csvReader.Read()
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			location := Location{Path: p.path, Row: parseErr.StartLine}
+			return layoutRecord{}, ErrMalformedRow{location: location, reason: parseErr.Err.Error()}
+		} else if err != nil {
+			return layoutRecord{}, err
+		}
+
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		lineNum, _ := p.csvReader.FieldPos(0)
+		return layoutRecord{values: values, lineNum: lineNum, mismatch: p.layout.check(values)}, nil
+	}
+
+	for p.scanner.Scan() {
+		p.lineNum++
+		line := strings.TrimRight(p.scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		values, mismatch := p.layout.split(line)
+		if mismatch == nil {
+			mismatch = p.layout.check(values)
+		}
+		return layoutRecord{values: values, lineNum: p.lineNum, mismatch: mismatch}, nil
+	}
+
+	if err := p.scanner.Err(); err != nil {
+		return layoutRecord{}, err
+	}
+	return layoutRecord{}, ErrEOF
+}
+
+// Close closes the file. No further operations will be possible.
+func (p RecordParser) Close() {
+	p.closer.Close()
+}
+
+// SetHeaderNames renames the layout's fields, allowing retrieval of columns by the new names
+func (p *RecordParser) SetHeaderNames(names []string) {
+	p.headers = names
+	p.index = newHeaderIndex(names, nil)
+}
+
+// Headers returns the names of the layout's fields
+func (p RecordParser) Headers() []string {
+	return p.headers
+}
+
+func (p RecordParser) headerIndex() headerIndex {
+	return p.index
+}
+
+// Path returns the path used for the file being parsed
+func (p RecordParser) Path() string {
+	return p.path
+}
+
+// Col returns the value of the field at the specified index
+func (r RecordRow) Col(index int) string {
+	if index < 0 || index > len(r.values)-1 {
+		return ""
+	}
+
+	return r.values[index]
+}
+
+// Cell returns the typed value of the field at the specified index
+func (r RecordRow) Cell(index int) Cell {
+	if index < 0 || index > len(r.values)-1 {
+		return Cell{Type: CellEmpty}
+	}
+
+	// Values were checked against the layout when the record was read
+	cell, _ := r.p.layout.Fields[index].cell(r.values[index])
+	return cell
+}
+
+// Headers returns the names of the layout's fields
+func (r RecordRow) Headers() []string {
+	return r.p.headers
+}
+
+// Location returns the file and line the record was read from
+func (r RecordRow) Location() Location {
+	return Location{Path: r.p.path, Row: r.lineNum}
+}
+
+func (r RecordRow) headerIndex() headerIndex {
+	return r.p.index
+}
+
+func (r RecordRow) report() *Report {
+	return r.p.report
+}
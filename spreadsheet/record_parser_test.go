@@ -0,0 +1,104 @@
+package spreadsheet
+
+import "testing"
+
+var spaceLayout = RecordLayout{
+	Name: "test",
+	Fields: []Field{
+		{Name: "Record"},
+		{Name: "Claim Number", Type: FieldInt},
+		{Name: "Sequence Number", Type: FieldInt},
+		{Name: "Amount", Type: FieldMoney},
+	},
+	Trailer: true,
+}
+
+func TestRecordParser(t *testing.T) {
+	t.Run("Allows retrieval of fields by name", func(t *testing.T) {
+		parser, err := NewParser(ParserInput{Path: "./testdata/space records.txt", Layout: &spaceLayout})
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "Claim Number", "1001")
+		if amount, err := MoneyColByName(row, "Amount"); err != nil || amount != 120.5 {
+			t.Errorf("Expected amount 120.5 but got %f, %v", amount, err)
+		}
+	})
+
+	t.Run("Skips trailing records that don't match the layout", func(t *testing.T) {
+		parser, _ := NewParser(ParserInput{Path: "./testdata/space records.txt", Layout: &spaceLayout})
+
+		total := 0
+		err := EachParserRow(parser, func(r Row) {
+			total++
+		})
+
+		if err != nil {
+			t.Errorf("Got an unexpected error %#v", err)
+		}
+		if total != 3 {
+			t.Errorf("Expected 3 records but got %d", total)
+		}
+	})
+
+	t.Run("Errors when records before the end don't match the layout", func(t *testing.T) {
+		parser, _ := NewParser(ParserInput{Path: "./testdata/space records mismatch.txt", Layout: &spaceLayout})
+
+		err := EachParserRow(parser, func(r Row) {})
+
+		if _, ok := err.(ErrLayoutMismatch); !ok {
+			t.Errorf("Expected ErrLayoutMismatch but got %#v", err)
+		}
+	})
+
+	t.Run("Errors on a mismatched trailer if trailers aren't allowed", func(t *testing.T) {
+		layout := spaceLayout
+		layout.Trailer = false
+		parser, _ := NewParser(ParserInput{Path: "./testdata/space records.txt", Layout: &layout})
+
+		err := EachParserRow(parser, func(r Row) {})
+
+		if _, ok := err.(ErrLayoutMismatch); !ok {
+			t.Errorf("Expected ErrLayoutMismatch but got %#v", err)
+		}
+	})
+
+	t.Run("Parses fixed width records", func(t *testing.T) {
+		layout := RecordLayout{
+			Name:       "fixed",
+			FixedWidth: true,
+			Trailer:    true,
+			Fields: []Field{
+				{Name: "Code", Start: 0, Width: 3},
+				{Name: "Claim Number", Type: FieldInt, Start: 3, Width: 4},
+				{Name: "Amount", Type: FieldInt, Start: 8, Width: 7},
+				{Name: "Date", Type: FieldDate, Start: 16, Width: 8, DateLayouts: []string{"20060102"}},
+			},
+		}
+		parser, _ := NewParser(ParserInput{Path: "./testdata/fixed width records.txt", Layout: &layout})
+
+		rows := []Row{}
+		err := EachParserRow(parser, func(r Row) {
+			rows = append(rows, r)
+		})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("Expected 2 records but got %d", len(rows))
+		}
+
+		AssertColumnNamed(t, rows[0], "Code", "ABC")
+		AssertColumnNamed(t, rows[0], "Amount", "0012050")
+		if date, err := DateColByName(rows[1], "Date"); err != nil || date.Day() != 7 {
+			t.Errorf("Expected the 7th but got %v, %v", date, err)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry parses each input once, keeping its rows in memory so everything reading the input
+// shares them rather than parsing the file again. Only files opened from a Path are shared, and
+// only between inputs with the same parsing options. Streamed inputs are kept too, they're still
+// parsed without loading the whole workbook but their rows are kept once. It's safe for concurrent use.
+type Registry struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// table is an input parsed into memory
+type table struct {
+	path    string
+	headers []string
+	index   headerIndex
+	rows    []Row
+	err     error // the error that stopped parsing, returned after the rows
+}
+
+// tableParser is a Parser over the rows of a table
+type tableParser struct {
+	t       *table
+	next    int
+	headers []string
+	index   headerIndex
+	renamed bool // if SetHeaderNames has replaced the table's headers
+}
+
+// renamedRow is a row from a tableParser whose headers have been replaced by SetHeaderNames
+type renamedRow struct {
+	Row
+	p *tableParser
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{tables: make(map[string]*table)}
+}
+
+// shares returns true if the rows of the input are kept by the registry, false if it should be parsed each time it's read
+func (r *Registry) shares(input ParserInput) bool {
+	return r != nil && input.Path != "" && input.Reader == nil && input.ReaderAt == nil
+}
+
+// parser returns a Parser over the rows of the input, parsing it the first time it's requested
+func (r *Registry) parser(input ParserInput) (Parser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := registryKey(input)
+	t, loaded := r.tables[key]
+	if !loaded {
+		var err error
+		t, err = loadTable(input)
+		if err != nil {
+			// Not cached so a file that's fixed can be tried again
+			return nil, err
+		}
+		r.tables[key] = t
+	}
+
+	parser := &tableParser{t: t, headers: t.headers, index: t.index}
+
+	// Inputs sharing the table can require different headers
+	err := AssertHeadersExist(parser, input.RequiredHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// registryKey identifies an input by its file and every option that changes how its rows are parsed
+func registryKey(input ParserInput) string {
+	// Options that only affect the first parse rather than the rows parsed
+	input.Report = nil
+	input.Registry = nil
+	input.Progress = nil
+	input.Context = nil
+
+	// Required headers are checked for each input, but can also decide which sheet and row the headers are in
+	if input.DetectHeaderRows == 0 && !input.FindSheetByHeaders {
+		input.RequiredHeaders = nil
+	}
+
+	return fmt.Sprintf("%#v", input)
+}
+
+// loadTable parses every row of the input
+func loadTable(input ParserInput) (*table, error) {
+	input.Registry = nil
+	parser, err := NewParser(input)
+	if err != nil {
+		return nil, err
+	}
+	defer parser.Close()
+
+	counter := progressCounter{progress: input.Progress}
+
+	t := &table{path: parser.Path(), headers: parser.Headers(), index: headerIndexFor(parser)}
+	for {
+		// Not cached so the input can be read again by a run that isn't cancelled
+		err := contextErr(input.Context)
+		if err != nil {
+			return nil, err
+		}
+
+		row, err := parser.Next()
+		if err == ErrEOF {
+			break
+		} else if err != nil {
+			t.err = err
+			break
+		}
+
+		t.rows = append(t.rows, row)
+		counter.add()
+	}
+	counter.done()
+
+	return t, nil
+}
+
+// Next returns the next row of the table, and then the error that stopped it being parsed if there was one
+func (p *tableParser) Next() (Row, error) {
+	if p.next >= len(p.t.rows) {
+		if p.t.err != nil {
+			return nil, p.t.err
+		}
+		return nil, ErrEOF
+	}
+
+	row := p.t.rows[p.next]
+	p.next++
+
+	if p.renamed {
+		return renamedRow{Row: row, p: p}, nil
+	}
+	return row, nil
+}
+
+// Close does nothing as the table stays in memory for other parsers
+func (p *tableParser) Close() {}
+
+// SetHeaderNames sets header names for the rows returned by this parser, allowing retrieval of columns by name
+func (p *tableParser) SetHeaderNames(names []string) {
+	p.headers = names
+	p.index = newHeaderIndex(names, nil)
+	p.renamed = true
+}
+
+// Headers returns the headers of the table
+func (p *tableParser) Headers() []string {
+	return p.headers
+}
+
+func (p *tableParser) headerIndex() headerIndex {
+	return p.index
+}
+
+// Path returns the path of the parsed file
+func (p *tableParser) Path() string {
+	return p.t.path
+}
+
+// Headers returns the headers set on the parser
+func (r renamedRow) Headers() []string {
+	return r.p.headers
+}
+
+func (r renamedRow) headerIndex() headerIndex {
+	return r.p.index
+}
+
+// Cell returns the typed value of the cell at the index
+func (r renamedRow) Cell(index int) Cell {
+	return cellAt(r.Row, index)
+}
+
+func (r renamedRow) report() *Report {
+	if reporting, ok := r.Row.(reportingRow); ok {
+		return reporting.report()
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package spreadsheet
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes the contents to the file at path
+func writeFile(t *testing.T, path string, contents string) {
+	t.Helper()
+
+	err := ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	readIDs := func(t *testing.T, input ParserInput) []string {
+		t.Helper()
+
+		parser, err := NewParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		ids := []string{}
+		err = EachParserRow(parser, func(r Row) {
+			ids = append(ids, ColByName(r, "ID"))
+		})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		return ids
+	}
+
+	t.Run("Parses an input once and shares its rows", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ids.csv")
+		writeFile(t, path, "ID,description\n1,first\n2,second\n")
+		input := ParserInput{Path: path, HasHeaders: true, Registry: NewRegistry()}
+
+		first := readIDs(t, input)
+		// The second read must come from the registry as the file has changed
+		writeFile(t, path, "ID,description\n3,third\n")
+		second := readIDs(t, input)
+
+		if strings.Join(first, ",") != "1,2" || strings.Join(second, ",") != "1,2" {
+			t.Errorf("Expected both reads to return rows 1,2 but got %v and %v", first, second)
+		}
+	})
+
+	t.Run("Parses inputs read from a reader each time", func(t *testing.T) {
+		registry := NewRegistry()
+		first := ParserInput{Reader: strings.NewReader("ID\n1\n"), Format: Csv, HasHeaders: true, Registry: registry}
+		second := ParserInput{Reader: strings.NewReader("Claim Number\n2\n"), Format: Csv, HasHeaders: true, RequiredHeaders: []string{"ID"}, Registry: registry}
+
+		readIDs(t, first)
+		if _, err := NewParser(second); err == nil {
+			t.Fatalf("Expected the second reader's headers to be checked")
+		}
+		if len(registry.tables) != 0 {
+			t.Errorf("Expected no tables to be kept but got %d", len(registry.tables))
+		}
+	})
+
+	t.Run("Parses an input again with different options", func(t *testing.T) {
+		registry := NewRegistry()
+		input := ParserInput{Path: "./testdata/csv with preamble.txt", HasHeaders: true, HeaderRow: 2, Registry: registry}
+
+		readIDs(t, input)
+		input.HeaderAliases = HeaderAliases{"Claim Number": {"ID"}}
+		parser, err := NewParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		AssertColumnNamed(t, row, "Claim Number", "1")
+	})
+
+	t.Run("Checks the required headers of each input", func(t *testing.T) {
+		registry := NewRegistry()
+		path := "./testdata/csv with headers.txt"
+
+		readIDs(t, ParserInput{Path: path, HasHeaders: true, Registry: registry})
+		_, err := NewParser(ParserInput{Path: path, HasHeaders: true, RequiredHeaders: []string{"Claim Number"}, Registry: registry})
+
+		if _, ok := err.(ErrMissingHeader); !ok {
+			t.Errorf("Expected ErrMissingHeader but got %#v", err)
+		}
+	})
+
+	t.Run("Parses a streamed input once and shares its rows", func(t *testing.T) {
+		parses := 0
+		registry := NewRegistry()
+		input := ParserInput{
+			Path:       "./testdata/Consent Report W360.xlsx",
+			HasHeaders: true,
+			Stream:     true,
+			Registry:   registry,
+			Progress: func(rows int, done bool) {
+				if done {
+					parses++
+				}
+			},
+		}
+
+		rows := []int{}
+		for i := 0; i < 2; i++ {
+			count := 0
+			err := EachRow(input, func(r Row) { count++ })
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+			rows = append(rows, count)
+		}
+
+		if parses != 1 || len(registry.tables) != 1 {
+			t.Errorf("Expected the input to be parsed once but got %d parses", parses)
+		}
+		if rows[0] == 0 || rows[0] != rows[1] {
+			t.Errorf("Expected the same rows from each read but got %v", rows)
+		}
+	})
+
+	t.Run("Returns the parse error on every read", func(t *testing.T) {
+		input := ParserInput{Path: "./testdata/csv with malformed rows.txt", HasHeaders: true, Registry: NewRegistry()}
+
+		for i := 0; i < 2; i++ {
+			parser, err := NewParser(input)
+			if err != nil {
+				t.Fatalf("Error creating parser %#v", err)
+			}
+
+			err = EachParserRow(parser, func(r Row) {})
+			if _, ok := err.(ErrMalformedRow); !ok {
+				t.Fatalf("Expected ErrMalformedRow on read %d but got %#v", i+1, err)
+			}
+		}
+	})
+
+	t.Run("Reports skipped rows once", func(t *testing.T) {
+		report := &Report{}
+		input := ParserInput{
+			Path:       "./testdata/csv with malformed rows.txt",
+			HasHeaders: true,
+			Policy:     Lenient,
+			Report:     report,
+			Registry:   NewRegistry(),
+		}
+
+		readIDs(t, input)
+		ids := readIDs(t, input)
+
+		if strings.Join(ids, ",") != "1,3" {
+			t.Errorf("Expected rows 1,3 but got %v", ids)
+		}
+		if len(report.Issues()) != 1 {
+			t.Errorf("Expected 1 issue but got %#v", report.Issues())
+		}
+	})
+
+	t.Run("Header names set on one parser don't change the shared rows", func(t *testing.T) {
+		input := ParserInput{Path: "./testdata/csv with headers.txt", HasHeaders: true, Registry: NewRegistry()}
+
+		parser, err := NewParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+		parser.SetHeaderNames([]string{"Renamed"})
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		AssertColumnNamed(t, row, "Renamed", "1")
+
+		if ids := readIDs(t, input); len(ids) == 0 || ids[0] != "1" {
+			t.Errorf("Expected the shared rows to keep their headers but got %v", ids)
+		}
+	})
+
+	t.Run("Reports the progress of the first parse only", func(t *testing.T) {
+		progress := []int{}
+		finished := false
+		input := ParserInput{
+			Path:       "./testdata/csv with headers.txt",
+			HasHeaders: true,
+			Registry:   NewRegistry(),
+			Progress: func(rows int, done bool) {
+				progress = append(progress, rows)
+				finished = done
+			},
+		}
+
+		ids := readIDs(t, input)
+		readIDs(t, input)
+
+		if len(progress) != 1 || progress[0] != len(ids) || !finished {
+			t.Errorf("Expected the %d rows to be reported once but got %v", len(ids), progress)
+		}
+	})
+
+	t.Run("Stops parsing when the context is cancelled, without keeping the rows", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		input := ParserInput{Path: "./testdata/csv with headers.txt", HasHeaders: true, Registry: NewRegistry(), Context: ctx}
+
+		err := EachRow(input, func(r Row) {})
+		if err != context.Canceled {
+			t.Fatalf("Expected the context's error but got %#v", err)
+		}
+
+		input.Context = context.Background()
+		if ids := readIDs(t, input); len(ids) == 0 {
+			t.Errorf("Expected the input to be parsed again but got no rows")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package spreadsheet
+
+import "sync"
+
+// ParsePolicy decides what happens when a malformed row is read
+type ParsePolicy int
+
+const (
+	// Strict fails on the first malformed row
+	Strict ParsePolicy = iota
+
+	// Lenient skips malformed rows, adding them to the input's Report, and carries on
+	Lenient
+)
+
+// Issue is a row that was skipped, or a cell whose value was replaced, while reading an input
+type Issue struct {
+	Location Location `json:"location"`
+	Column   string   `json:"column,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Action   string   `json:"action"`
+	Reason   string   `json:"reason"`
+}
+
+// Report collects the issues found while reading inputs. It's safe to share between parsers and goroutines.
+type Report struct {
+	mu     sync.Mutex
+	issues []Issue
+	seen   map[Issue]bool
+}
+
+// Add records an issue. Issues already recorded are ignored, e.g. when rows shared by a Registry are read again.
+func (r *Report) Add(issue Issue) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seen[issue] {
+		return
+	}
+	if r.seen == nil {
+		r.seen = make(map[Issue]bool)
+	}
+	r.seen[issue] = true
+
+	r.issues = append(r.issues, issue)
+}
+
+// Issues returns the issues recorded so far, in the order they were found
+func (r *Report) Issues() []Issue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	issues := make([]Issue, len(r.issues))
+	copy(issues, r.issues)
+	return issues
+}
+
+// reportingRow is implemented by rows from a parser that may have a Report
+type reportingRow interface {
+	report() *Report
+}
+
+// ReportSkippedRow records that the row wasn't used because of err.
+// Does nothing if the row's input doesn't have a Report.
+func ReportSkippedRow(r Row, err error) {
+	addIssue(r, Issue{Location: r.Location(), Action: "skipped row", Reason: err.Error()})
+}
+
+// ReportReplacedCell records that the value in the column couldn't be used because of err, and
+// replacement was used instead. Does nothing if the row's input doesn't have a Report.
+func ReportReplacedCell(r Row, column string, replacement string, err error) {
+	addIssue(r, Issue{
+		Location: r.Location(),
+		Column:   column,
+		Value:    ColByName(r, column),
+		Action:   "replaced with " + replacement,
+		Reason:   err.Error(),
+	})
+}
+
+func addIssue(r Row, issue Issue) {
+	if reporting, ok := r.(reportingRow); ok && reporting.report() != nil {
+		reporting.report().Add(issue)
+	}
+}
+
+// skipMalformedRow records the malformed row and returns true if it should be skipped under the policy
+func skipMalformedRow(policy ParsePolicy, report *Report, err error) bool {
+	if policy != Lenient {
+		return false
+	}
+
+	var location Location
+	switch err := err.(type) {
+	case ErrMalformedRow:
+		location = err.Location()
+	case ErrLayoutMismatch:
+		location = err.Location()
+	default:
+		return false
+	}
+
+	if report != nil {
+		report.Add(Issue{Location: location, Action: "skipped row", Reason: err.Error()})
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package spreadsheet
+
+import "testing"
+
+func TestParsePolicy(t *testing.T) {
+	path := "./testdata/csv with malformed rows.txt"
+
+	t.Run("Strict fails on a malformed row", func(t *testing.T) {
+		parser, err := NewParser(ParserInput{Path: path, HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		err = EachParserRow(parser, func(r Row) {})
+
+		malformed, ok := err.(ErrMalformedRow)
+		if !ok {
+			t.Fatalf("Expected ErrMalformedRow but got %#v", err)
+		}
+		if malformed.Location().Row != 3 {
+			t.Errorf("Expected line 3 but got %s", malformed.Location())
+		}
+	})
+
+	t.Run("Lenient skips and reports malformed rows", func(t *testing.T) {
+		report := &Report{}
+		parser, err := NewParser(ParserInput{Path: path, HasHeaders: true, Policy: Lenient, Report: report})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		ids := []string{}
+		err = EachParserRow(parser, func(r Row) {
+			ids = append(ids, ColByName(r, "ID"))
+		})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if len(ids) != 2 || ids[0] != "1" || ids[1] != "3" {
+			t.Errorf("Expected rows 1 and 3 but got %v", ids)
+		}
+
+		issues := report.Issues()
+		if len(issues) != 1 || issues[0].Location.Row != 3 || issues[0].Action != "skipped row" {
+			t.Errorf("Expected line 3 to be reported as skipped but got %#v", issues)
+		}
+	})
+
+	t.Run("Checks rows read while detecting the header row", func(t *testing.T) {
+		input := ParserInput{Path: path, HasHeaders: true, DetectHeaderRows: 5, RequiredHeaders: []string{"ID"}}
+		parser, err := NewParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		err = EachParserRow(parser, func(r Row) {})
+
+		if malformed, ok := err.(ErrMalformedRow); !ok || malformed.Location().Row != 3 {
+			t.Fatalf("Expected ErrMalformedRow at line 3 but got %#v", err)
+		}
+
+		report := &Report{}
+		input.Policy = Lenient
+		input.Report = report
+		parser, err = NewParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+
+		ids := []string{}
+		err = EachParserRow(parser, func(r Row) {
+			ids = append(ids, ColByName(r, "ID"))
+		})
+
+		if err != nil || len(ids) != 2 || ids[0] != "1" || ids[1] != "3" {
+			t.Errorf("Expected rows 1 and 3 but got %v (%v)", ids, err)
+		}
+		if issues := report.Issues(); len(issues) != 1 || issues[0].Location.Row != 3 {
+			t.Errorf("Expected line 3 to be reported as skipped but got %#v", issues)
+		}
+	})
+
+	t.Run("Lenient skips records that don't match a layout", func(t *testing.T) {
+		report := &Report{}
+		parser, _ := NewParser(ParserInput{Path: "./testdata/space records mismatch.txt", Layout: &spaceLayout, Policy: Lenient, Report: report})
+
+		total := 0
+		err := EachParserRow(parser, func(r Row) {
+			total++
+		})
+
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		if total != 3 {
+			t.Errorf("Expected 3 records but got %d", total)
+		}
+		if issues := report.Issues(); len(issues) != 1 || issues[0].Location.Row != 3 {
+			t.Errorf("Expected line 3 to be reported as skipped but got %#v", issues)
+		}
+	})
+
+	t.Run("Reports replaced cells", func(t *testing.T) {
+		report := &Report{}
+		parser, _ := NewParser(ParserInput{Path: "./testdata/csv with headers.txt", HasHeaders: true, Report: report})
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		if value := FloatColByName(row, "description"); value != 0 {
+			t.Errorf("Expected 0 but got %f", value)
+		}
+
+		issues := report.Issues()
+		if len(issues) != 1 || issues[0].Column != "description" || issues[0].Action != "replaced with 0" {
+			t.Errorf("Expected the description to be reported as replaced but got %#v", issues)
+		}
+	})
+}
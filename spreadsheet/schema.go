@@ -0,0 +1,173 @@
+package spreadsheet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Column describes a column expected in an input
+type Column struct {
+	Name string
+	Type FieldType
+
+	// Position is the 1-based position of the column for inputs read by position rather than
+	// header name. 0 finds the column by Name.
+	Position int
+
+	// Optional allows the column to be missing from the input
+	Optional bool
+
+	// Required means every row must have a value in the column
+	Required bool
+
+	// DateLayouts are the time layouts accepted for FieldDate columns
+	DateLayouts []string
+
+	// Allowed lists the values the column can have, ignoring case. Empty allows any value.
+	Allowed []string
+
+	// Unique means no two rows can have the same value in the column
+	Unique bool
+}
+
+// Schema describes the columns of an input
+type Schema struct {
+	Name    string
+	Columns []Column
+}
+
+// RequiredHeaders returns the names of the columns that must be in the input's header row
+func (s Schema) RequiredHeaders() []string {
+	headers := []string{}
+	for _, column := range s.Columns {
+		if column.Position == 0 && !column.Optional && indexOf(headers, column.Name) < 0 {
+			headers = append(headers, column.Name)
+		}
+	}
+	return headers
+}
+
+// Validate reads every row of the input and checks it against the schema, returning every problem
+// found in the order they appear. Malformed rows are included rather than stopping validation, as is
+// an input that can't be read at all.
+func Validate(input ParserInput, schema Schema) []Issue {
+	report := &Report{}
+	input.Policy = Lenient
+	input.Report = report
+	input.Registry = nil
+	if len(input.RequiredHeaders) == 0 {
+		// Used to find the header row and sheet
+		input.RequiredHeaders = schema.RequiredHeaders()
+	}
+
+	unreadable := func(err error) []Issue {
+		return []Issue{{Location: Location{Path: input.Path}, Action: "unreadable", Reason: err.Error()}}
+	}
+
+	// Missing headers are still returned with the parser, they're all reported below
+	parser, err := NewParser(input)
+	if _, missingHeader := err.(ErrMissingHeader); err != nil && !missingHeader {
+		return unreadable(err)
+	}
+	defer parser.Close()
+
+	issues := []Issue{}
+	columns := []schemaColumn{}
+	headers := headerIndexFor(parser)
+	for _, column := range schema.Columns {
+		index := column.Position - 1
+		if column.Position == 0 {
+			index = headers.colIndex(column.Name)
+		}
+
+		if index < 0 {
+			if !column.Optional {
+				missing := ErrMissingHeader{filePath: input.Path, header: column.Name}
+				issues = append(issues, Issue{Location: Location{Path: input.Path}, Column: column.Name, Action: "missing column", Reason: missing.Error()})
+			}
+			continue
+		}
+
+		columns = append(columns, schemaColumn{Column: column, index: index, seen: make(map[string]Location)})
+	}
+
+	for {
+		row, err := parser.Next()
+		if err == ErrEOF {
+			break
+		} else if err != nil {
+			issues = append(issues, unreadable(err)...)
+			break
+		}
+
+		for _, column := range columns {
+			if issue, ok := column.check(row); !ok {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	issues = append(report.Issues(), issues...)
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Location.Row < issues[j].Location.Row
+	})
+	return issues
+}
+
+// schemaColumn is a Column found in the input being validated
+type schemaColumn struct {
+	Column
+	index int
+	seen  map[string]Location // values already found in Unique columns
+}
+
+// check returns an issue describing how the column's value in the row doesn't match the schema, if it doesn't
+func (c schemaColumn) check(r Row) (Issue, bool) {
+	value := strings.TrimSpace(r.Col(c.index))
+	invalid := func(reason string) (Issue, bool) {
+		return Issue{Location: r.Location(), Column: c.Name, Value: value, Action: "invalid", Reason: reason}, false
+	}
+
+	if value == "" {
+		if c.Required {
+			return invalid(ErrEmptyCell{location: r.Location(), header: c.Name}.Error())
+		}
+		return Issue{}, true
+	}
+
+	var err error
+	switch c.Type {
+	case FieldInt:
+		_, err = intCol(r, c.index, c.Name)
+	case FieldMoney:
+		_, err = moneyCol(r, c.index, c.Name)
+	case FieldDate:
+		_, err = dateCol(r, c.index, c.Name, c.DateLayouts...)
+	}
+	if err != nil {
+		return invalid(err.Error())
+	}
+
+	if len(c.Allowed) > 0 && !containsFold(c.Allowed, value) {
+		return invalid(fmt.Sprintf(`Expected one of "%s"`, strings.Join(c.Allowed, `", "`)))
+	}
+
+	if c.Unique {
+		if first, seen := c.seen[value]; seen {
+			return invalid(fmt.Sprintf("Duplicate of the value at %s", first))
+		}
+		c.seen[value] = r.Location()
+	}
+
+	return Issue{}, true
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
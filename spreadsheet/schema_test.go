@@ -0,0 +1,58 @@
+package spreadsheet
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	schema := Schema{
+		Name: "test",
+		Columns: []Column{
+			{Name: "Claim Number", Type: FieldInt, Required: true, Unique: true},
+			{Name: "Indicator", Allowed: []string{"income support", "standard", "ESA(IR)"}},
+			{Name: "Amount", Type: FieldMoney},
+			{Name: "Start Date", Type: FieldDate, DateLayouts: []string{"02/01/2006"}},
+			{Name: "Notes", Optional: true},
+			{Name: "Postcode"},
+		},
+	}
+
+	issues := Validate(ParserInput{Path: "./testdata/schema.csv", HasHeaders: true}, schema)
+
+	expected := []struct {
+		row    int
+		column string
+		action string
+	}{
+		{0, "Postcode", "missing column"},
+		{4, "Claim Number", "invalid"},
+		{4, "Indicator", "invalid"},
+		{4, "Amount", "invalid"},
+		{4, "Start Date", "invalid"},
+		{5, "Claim Number", "invalid"},
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues but got %d: %#v", len(expected), len(issues), issues)
+	}
+
+	for i, want := range expected {
+		got := issues[i]
+		if got.Location.Row != want.row || got.Column != want.column || got.Action != want.action {
+			t.Errorf("Expected %s %s on row %d but got %#v", want.action, want.column, want.row, got)
+		}
+	}
+
+	t.Run("Reports inputs that can't be read", func(t *testing.T) {
+		issues := Validate(ParserInput{Path: "./testdata/missing.csv"}, schema)
+
+		if len(issues) != 1 || issues[0].Action != "unreadable" {
+			t.Errorf("Expected the input to be unreadable but got %#v", issues)
+		}
+	})
+
+	t.Run("Lists the required headers", func(t *testing.T) {
+		headers := schema.RequiredHeaders()
+		if len(headers) != 5 || indexOf(headers, "Notes") >= 0 {
+			t.Errorf("Expected all headers except Notes but got %v", headers)
+		}
+	})
+}
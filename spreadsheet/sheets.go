@@ -0,0 +1,91 @@
+package spreadsheet
+
+import (
+	"fmt"
+
+	"github.com/extrame/xls"
+)
+
+// ListSheets returns the names of the sheets in the workbook, in order.
+// Text formats such as CSV don't have sheets and return an empty list.
+func ListSheets(input ParserInput) ([]string, error) {
+	input, err := detectFormat(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch input.Format {
+	case Xls:
+		reader, closer, err := openSizedInput(input)
+		if err != nil {
+			return nil, err
+		}
+		defer closer.Close()
+
+		workbook, err := xls.OpenReader(reader, "utf-8")
+		if err != nil {
+			return nil, ErrUnableToParse{input.Path}
+		}
+
+		return xlsSheetNames(workbook), nil
+	case Xlsx:
+		stream, err := openXlsxStream(input)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.close()
+
+		return stream.sheetNames(), nil
+	}
+
+	return []string{}, nil
+}
+
+// selectSheet returns the index of the sheet that should be parsed based on the
+// input's sheet options. rowsAt returns the first rows of the sheet at an index, enough
+// to find the header row.
+func selectSheet(input ParserInput, names []string, rowsAt func(int) [][]string) (int, error) {
+	if input.FindSheetByHeaders {
+		for index := range names {
+			headers := newHeaderIndex(headerRowValues(input, rowsAt(index)), input.HeaderAliases)
+			if hasAllHeaders(headers, input.RequiredHeaders) {
+				return index, nil
+			}
+		}
+
+		return -1, ErrNoSheetWithHeaders{filePath: input.Path, headers: input.RequiredHeaders}
+	}
+
+	if input.SheetName != "" {
+		index := indexOf(names, input.SheetName)
+		if index < 0 {
+			return -1, ErrMissingSheet{filePath: input.Path, sheet: input.SheetName}
+		}
+
+		return index, nil
+	}
+
+	if input.SheetIndex < 0 || input.SheetIndex >= len(names) {
+		return -1, ErrMissingSheet{filePath: input.Path, sheet: fmt.Sprintf("%d", input.SheetIndex)}
+	}
+
+	return input.SheetIndex, nil
+}
+
+func hasAllHeaders(headers headerIndex, expectedHeaders []string) bool {
+	for _, hdr := range expectedHeaders {
+		if headers.colIndex(hdr) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func xlsSheetNames(workbook *xls.WorkBook) []string {
+	names := []string{}
+	for i := 0; i < workbook.NumSheets(); i++ {
+		names = append(names, workbook.GetSheet(i).Name)
+	}
+	return names
+}
@@ -0,0 +1,88 @@
+package spreadsheet
+
+import "testing"
+
+func TestListSheets(t *testing.T) {
+	t.Run("Lists sheets in xlsx workbooks", func(t *testing.T) {
+		sheets, err := ListSheets(ParserInput{Path: "./testdata/Consent Report W360.xlsx"})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		if len(sheets) != 1 || sheets[0] != "Sheet1" {
+			t.Fatalf(`Expected ["Sheet1"] but got %#v`, sheets)
+		}
+	})
+
+	t.Run("Lists sheets in xls workbooks", func(t *testing.T) {
+		sheets, err := ListSheets(ParserInput{Path: "./testdata/Consent Report W360.xls"})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		if len(sheets) != 1 {
+			t.Fatalf(`Expected 1 sheet but got %#v`, sheets)
+		}
+	})
+
+	t.Run("Returns no sheets for text files", func(t *testing.T) {
+		sheets, err := ListSheets(ParserInput{Path: "./testdata/csv with headers.txt"})
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		if len(sheets) != 0 {
+			t.Fatalf(`Expected no sheets but got %#v`, sheets)
+		}
+	})
+}
+
+func TestSheetSelection(t *testing.T) {
+	path := "./testdata/Consent Report W360.xlsx"
+
+	t.Run("Selects sheets by name", func(t *testing.T) {
+		for _, stream := range []bool{false, true} {
+			parser, err := NewXlsxParser(ParserInput{Path: path, HasHeaders: true, SheetName: "Sheet1", Stream: stream})
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+			parser.Close()
+		}
+	})
+
+	t.Run("Errors when the named sheet is missing", func(t *testing.T) {
+		for _, stream := range []bool{false, true} {
+			_, err := NewXlsxParser(ParserInput{Path: path, HasHeaders: true, SheetName: "Notes", Stream: stream})
+			if _, ok := err.(ErrMissingSheet); !ok {
+				t.Fatalf("Expected ErrMissingSheet but got %#v", err)
+			}
+		}
+	})
+
+	t.Run("Errors when the sheet index is out of range", func(t *testing.T) {
+		_, err := NewXlsParser(ParserInput{Path: "./testdata/Consent Report W360.xls", SheetIndex: 1})
+		if _, ok := err.(ErrMissingSheet); !ok {
+			t.Fatalf("Expected ErrMissingSheet but got %#v", err)
+		}
+	})
+
+	t.Run("Finds the first sheet with the required headers", func(t *testing.T) {
+		input := ParserInput{Path: path, HasHeaders: true, FindSheetByHeaders: true, RequiredHeaders: []string{"DocDesc", "CLAIMREFERENCE"}}
+		parser, err := NewXlsxParser(input)
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+		AssertColumnNamed(t, row, "CLAIMREFERENCE", "000017")
+
+		input.RequiredHeaders = []string{"Claim Number"}
+		_, err = NewXlsxParser(input)
+		if _, ok := err.(ErrNoSheetWithHeaders); !ok {
+			t.Fatalf("Expected ErrNoSheetWithHeaders but got %#v", err)
+		}
+	})
+}
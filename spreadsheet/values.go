@@ -0,0 +1,106 @@
+package spreadsheet
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// errInvalidValue is returned by the Parse functions, and converted to ErrInvalidValue by the col helpers
+	errInvalidValue = errors.New("Invalid value")
+
+	// excelEpoch is day 0 for excel serial dates. Excel treats 1900 as a leap year so the epoch is
+	// 30th December 1899 rather than the 31st.
+	excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+)
+
+const (
+	// maxSerialDate is the last excel serial date, 31st December 9999
+	maxSerialDate = 2958465
+
+	// minTextSerialDate and maxTextSerialDate are the range of serial dates accepted in text, 1st January 1920
+	// to 31st December 2099, so a small number such as an age isn't read as a date early in 1900
+	minTextSerialDate = 7306
+	maxTextSerialDate = 73050
+)
+
+// ParseDate parses a date using the first matching layout. Excel serial dates from
+// 1920 to 2099, e.g. 43446 for 12th December 2018, are also accepted.
+func ParseDate(value string, layouts ...string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < minTextSerialDate || serial >= maxTextSerialDate+1 {
+		return time.Time{}, errInvalidValue
+	}
+
+	return serialDate(serial)
+}
+
+// serialDate converts an excel serial date, such as the value of a numeric cell, to a time
+func serialDate(serial float64) (time.Time, error) {
+	if math.IsNaN(serial) || serial <= 0 || serial >= maxSerialDate+1 {
+		return time.Time{}, errInvalidValue
+	}
+
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 24 * 60 * 60)
+	return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second), nil
+}
+
+// ParseInt parses a whole number, allowing thousands separators and a ".0" decimal part
+func ParseInt(value string) (int, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+
+	// Only zeros are allowed after the decimal point, e.g. "12.0" from a number formatted with decimals
+	if point := strings.Index(value, "."); point >= 0 {
+		if strings.Trim(value[point+1:], "0") != "" {
+			return 0, errInvalidValue
+		}
+		value = value[:point]
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errInvalidValue
+	}
+
+	return number, nil
+}
+
+// ParseMoney parses a currency amount such as "£1,234.50", "-12" or "(12.00)". "NaN" and "Inf" aren't amounts.
+func ParseMoney(value string) (float32, error) {
+	value = strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	}
+
+	value = strings.NewReplacer("£", "", "$", "", "€", "", ",", "", " ", "").Replace(value)
+	amount, err := strconv.ParseFloat(value, 32)
+	if err != nil || !isFinite(amount) {
+		return 0, errInvalidValue
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return float32(amount), nil
+}
+
+// isFinite returns false for NaN and infinite numbers, which ParseFloat accepts but aren't values in a spreadsheet
+func isFinite(number float64) bool {
+	return !math.IsNaN(number) && !math.IsInf(number, 0)
+}
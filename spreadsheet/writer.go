@@ -0,0 +1,14 @@
+package spreadsheet
+
+// Writer writes sheets of typed cells to an output. Formats with a single sheet per file, i.e. CSV,
+// write each sheet to its own file.
+type Writer interface {
+	// AddSheet starts a new sheet with a header row, rows written after are added to it
+	AddSheet(name string, headers []string) error
+
+	// Write adds a row to the current sheet
+	Write(cells []Cell) error
+
+	// Close finishes the current sheet and saves the output
+	Close() error
+}
@@ -0,0 +1,106 @@
+package spreadsheet
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+func TestWriters(t *testing.T) {
+	dob := time.Date(2012, 3, 4, 0, 0, 0, 0, time.UTC)
+	headers := []string{"Reference", "Score", "Date of Birth"}
+	cells := []Cell{TextCell("000123"), NumberCell(0.5), DateCell(dob, "02-01-2006")}
+
+	write := func(t *testing.T, w Writer) {
+		t.Helper()
+
+		for _, sheet := range []string{"First", "Second"} {
+			if err := w.AddSheet(sheet, headers); err != nil {
+				t.Fatalf("Error adding sheet %#v", err)
+			}
+			if err := w.Write(cells); err != nil {
+				t.Fatalf("Error writing row %#v", err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatalf("Error closing writer %#v", err)
+		}
+	}
+
+	t.Run("CSV writes each sheet to its own file", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, NewCsvWriter(func(name string) string {
+			return filepath.Join(dir, name+".csv")
+		}))
+
+		for _, sheet := range []string{"First", "Second"} {
+			parser, err := NewParser(ParserInput{Path: filepath.Join(dir, sheet+".csv"), HasHeaders: true})
+			if err != nil {
+				t.Fatalf("Error creating parser %#v", err)
+			}
+
+			row, err := parser.Next()
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			AssertColumnNamed(t, row, "Reference", "000123")
+			AssertColumnNamed(t, row, "Score", "0.5")
+			AssertColumnNamed(t, row, "Date of Birth", "04-03-2012")
+			parser.Close()
+		}
+	})
+
+	t.Run("XLSX writes typed cells to sheets of one workbook", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output.xlsx")
+		write(t, NewXlsxWriter(path))
+
+		sheets, err := ListSheets(ParserInput{Path: path})
+		if err != nil {
+			t.Fatalf("Error listing sheets %#v", err)
+		}
+		if len(sheets) != 2 || sheets[0] != "First" || sheets[1] != "Second" {
+			t.Fatalf("Expected sheets First and Second but got %v", sheets)
+		}
+
+		parser, err := NewParser(ParserInput{Path: path, HasHeaders: true, SheetName: "Second"})
+		if err != nil {
+			t.Fatalf("Error creating parser %#v", err)
+		}
+		defer parser.Close()
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		if cell := CellByName(row, "Reference"); cell.Type != CellString || cell.Text != "000123" {
+			t.Errorf("Expected the reference as text but got %#v", cell)
+		}
+		if cell := CellByName(row, "Score"); cell.Type != CellNumber || cell.Number != 0.5 {
+			t.Errorf("Expected the score as a number but got %#v", cell)
+		}
+		if cell := CellByName(row, "Date of Birth"); cell.Type != CellDate || !cell.Date.Equal(dob) {
+			t.Errorf("Expected the date of birth as a date but got %#v", cell)
+		}
+
+		file, err := xlsx.OpenFile(path)
+		if err != nil {
+			t.Fatalf("Error opening workbook %#v", err)
+		}
+		views := file.Sheets[0].SheetViews
+		if len(views) == 0 || views[0].Pane == nil || views[0].Pane.State != "frozen" || views[0].Pane.YSplit != 1 {
+			t.Errorf("Expected the header row to be frozen but got %#v", views)
+		}
+	})
+
+	t.Run("Rows can't be written before a sheet", func(t *testing.T) {
+		w := NewXlsxWriter(filepath.Join(t.TempDir(), "output.xlsx"))
+		if _, ok := w.Write(cells).(ErrNoSheet); !ok {
+			t.Errorf("Expected ErrNoSheet")
+		}
+	})
+}
@@ -15,33 +15,51 @@ type XlsParser struct {
 	currentRow int
 	headers    []string
 	hasHeaders bool
+	aliases    HeaderAliases
+	index      headerIndex
+	report     *Report
 }
 
 // XlsRow represents a row in an Xls sheet
 type XlsRow struct {
-	p   *XlsParser
-	row *xls.Row
+	p     *XlsParser
+	row   *xls.Row
+	index int
 }
 
-// NewXlsParser creates an XlsParser from a given file path
+// NewXlsParser creates an XlsParser from a given input
 func NewXlsParser(input ParserInput) (*XlsParser, error) {
-	workbook, closer, err := xls.OpenWithCloser(input.Path, "utf-8")
+	reader, closer, err := openSizedInput(input)
 	if err != nil {
 		return nil, err
 	}
 
-	sheet := workbook.GetSheet(0)
+	workbook, err := xls.OpenReader(reader, "utf-8")
+	if err != nil {
+		closer.Close()
+		return nil, err
+	}
+
+	sheetIndex, err := selectSheet(input, xlsSheetNames(workbook), func(index int) [][]string {
+		return xlsFirstRows(workbook.GetSheet(index), headerScanLength(input))
+	})
+	if err != nil {
+		closer.Close()
+		return nil, err
+	}
+
+	sheet := workbook.GetSheet(sheetIndex)
 	if sheet == nil {
+		closer.Close()
 		return nil, ErrUnableToParse{input.Path}
 	}
 
 	var headers []string
+	headerRow := 0
 	if input.HasHeaders {
-		headerRow := sheet.Row(0)
-		for i := 0; i <= headerRow.LastCol(); i++ {
-			cell := headerRow.Col(i)
-			headers = append(headers, cell)
-		}
+		firstRows := xlsFirstRows(sheet, headerScanLength(input))
+		headerRow = findHeaderRow(input, firstRows)
+		headers = headerRowValues(input, firstRows)
 	}
 
 	parser := &XlsParser{
@@ -49,9 +67,12 @@ func NewXlsParser(input ParserInput) (*XlsParser, error) {
 		workbook:   workbook,
 		sheet:      sheet,
 		closer:     closer,
-		currentRow: 0,
+		currentRow: headerRow,
 		hasHeaders: input.HasHeaders,
 		headers:    headers,
+		aliases:    input.HeaderAliases,
+		index:      newHeaderIndex(headers, input.HeaderAliases),
+		report:     input.Report,
 	}
 
 	err = AssertHeadersExist(parser, input.RequiredHeaders)
@@ -68,7 +89,7 @@ func (p *XlsParser) Next() (Row, error) {
 
 	p.currentRow = nextRow
 	row := p.sheet.Row(nextRow)
-	return XlsRow{p: p, row: row}, nil
+	return XlsRow{p: p, row: row, index: nextRow}, nil
 }
 
 // Close closes the spreadsheet, making it unavailable for further operations
@@ -80,6 +101,7 @@ func (p XlsParser) Close() {
 func (p *XlsParser) SetHeaderNames(names []string) {
 	p.headers = names
 	p.hasHeaders = true
+	p.index = newHeaderIndex(names, p.aliases)
 }
 
 // Headers returns the headers found or set on the current parsed file
@@ -87,6 +109,10 @@ func (p XlsParser) Headers() []string {
 	return p.headers
 }
 
+func (p XlsParser) headerIndex() headerIndex {
+	return p.index
+}
+
 // Path returns the path used for the file being parsed
 func (p XlsParser) Path() string {
 	return p.path
@@ -94,10 +120,64 @@ func (p XlsParser) Path() string {
 
 // Col returns the string in the specified column
 func (r XlsRow) Col(index int) string {
+	if r.row == nil {
+		// Rows without any cells are missing from the sheet
+		return ""
+	}
+
 	return r.row.Col(index)
 }
 
+// Cell returns the typed value in the specified column. The xls library doesn't expose the number
+// format of a cell's record, only its formatted text, so the type is inferred from the text rather
+// than read from the file: built in date formats are output as RFC3339 and general numbers as plain
+// numbers. This means the type can differ from the xlsx parser's for the same data:
+//   - dates in custom formats, e.g. "dd/mm/yy h:mm:ss", are CellString with the formatted date
+//   - dates in custom formats the library treats as numbers, e.g. containing "m/y", are CellNumber
+//   - numbers stored at full precision in any format but General are formatted by the library
+//     as if they were dates, and are CellString
+//
+// Use DateColByName, MoneyColByName etc. with layouts for the text rather than relying on the type.
+func (r XlsRow) Cell(index int) Cell {
+	return inferredCell(r.Col(index))
+}
+
 // Headers returns the headers from the XLS
 func (r XlsRow) Headers() []string {
 	return r.p.headers
 }
+
+// Location returns the file, sheet and row number the row was read from
+func (r XlsRow) Location() Location {
+	return Location{Path: r.p.path, Sheet: r.p.sheet.Name, Row: r.index + 1}
+}
+
+func (r XlsRow) headerIndex() headerIndex {
+	return r.p.index
+}
+
+func (r XlsRow) report() *Report {
+	return r.p.report
+}
+
+// xlsRowValues returns all the cells in the row, rows with no cells are missing from the sheet (nil)
+func xlsRowValues(row *xls.Row) []string {
+	values := []string{}
+	if row == nil {
+		return values
+	}
+
+	for i := 0; i <= row.LastCol(); i++ {
+		values = append(values, row.Col(i))
+	}
+	return values
+}
+
+// xlsFirstRows returns the cells for up to n rows from the top of the sheet
+func xlsFirstRows(sheet *xls.WorkSheet, n int) [][]string {
+	rows := [][]string{}
+	for i := 0; i < n && i <= int(sheet.MaxRow); i++ {
+		rows = append(rows, xlsRowValues(sheet.Row(i)))
+	}
+	return rows
+}
@@ -21,3 +21,21 @@ func TestXlsNext(t *testing.T) {
 		AssertColumnNamed(t, row, "DocDate", "12/12/18 1:23:45")
 	})
 }
+
+func TestXlsLocation(t *testing.T) {
+	path := "./testdata/Consent Report W360.xls"
+	parser, err := NewXlsParser(ParserInput{Path: path, HasHeaders: true})
+	if err != nil {
+		t.Fatalf("Error creating parser")
+	}
+
+	row, err := parser.Next()
+	if err != nil {
+		t.Fatalf("Got an unexpected error %#v", err)
+	}
+
+	location := row.Location()
+	if location.Path != path || location.Sheet == "" || location.Row != 2 {
+		t.Errorf("Expected row 2 of a sheet in %s but got %s", path, location)
+	}
+}
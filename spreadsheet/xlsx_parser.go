@@ -1,6 +1,8 @@
 package spreadsheet
 
 import (
+	"io"
+
 	"github.com/tealeg/xlsx"
 )
 
@@ -9,47 +11,143 @@ type XlsxParser struct {
 	path       string
 	file       *xlsx.File
 	sheet      *xlsx.Sheet
+	stream     *xlsxStream
+	sheetName  string
+	buffered   []XlsxRow // rows read from the stream while finding the header row
 	currentRow int
 	numRows    int
+	date1904   bool
 	hasHeaders bool
 	headers    []string
+	aliases    HeaderAliases
+	index      headerIndex
+	report     *Report
 }
 
 // XlsxRow is a spreadsheet.Row implementation for Xlsx files
 type XlsxRow struct {
-	p   *XlsxParser
-	row *xlsx.Row
+	p     *XlsxParser
+	row   *xlsx.Row
+	cells []Cell // used instead of row when streaming
+	index int
 }
 
-// NewXlsxParser returns an XlsxParser for the file at the given path
+// NewXlsxParser returns an XlsxParser for the given input
 func NewXlsxParser(input ParserInput) (*XlsxParser, error) {
-	xlFile, err := xlsx.OpenFile(input.Path)
+	if input.Stream {
+		return newStreamingXlsxParser(input)
+	}
+
+	reader, closer, err := openSizedInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// The whole workbook is loaded so the file isn't needed after opening
+	xlFile, err := xlsx.OpenReaderAt(reader, reader.Size())
+	closer.Close()
 	if err != nil {
 		return nil, ErrUnableToParse{input.Path}
 	}
 
-	sheet := xlFile.Sheets[0]
+	sheetNames := []string{}
+	for _, sheet := range xlFile.Sheets {
+		sheetNames = append(sheetNames, sheet.Name)
+	}
+
+	sheetIndex, err := selectSheet(input, sheetNames, func(index int) [][]string {
+		return xlsxFirstRows(xlFile.Sheets[index], headerScanLength(input))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := xlFile.Sheets[sheetIndex]
 
 	if sheet == nil {
 		return nil, ErrUnableToParse{input.Path}
 	}
 
 	var headers []string
+	headerRow := 0
 	if input.HasHeaders {
-		headerRow := sheet.Row(0)
-		for _, cell := range headerRow.Cells {
-			headers = append(headers, cell.String())
-		}
+		firstRows := xlsxFirstRows(sheet, headerScanLength(input))
+		headerRow = findHeaderRow(input, firstRows)
+		headers = headerRowValues(input, firstRows)
 	}
 
 	parser := &XlsxParser{
 		path:       input.Path,
 		file:       xlFile,
 		sheet:      sheet,
-		currentRow: 0,
+		sheetName:  sheet.Name,
+		currentRow: headerRow,
 		numRows:    sheet.MaxRow,
+		date1904:   xlFile.Date1904,
+		hasHeaders: input.HasHeaders,
+		headers:    headers,
+		aliases:    input.HeaderAliases,
+		index:      newHeaderIndex(headers, input.HeaderAliases),
+		report:     input.Report,
+	}
+
+	err = AssertHeadersExist(parser, input.RequiredHeaders)
+
+	return parser, err
+}
+
+// newStreamingXlsxParser returns an XlsxParser that reads rows straight from the sheet xml
+// rather than loading the whole workbook into memory
+func newStreamingXlsxParser(input ParserInput) (*XlsxParser, error) {
+	stream, err := openXlsxStream(input)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetIndex, err := selectSheet(input, stream.sheetNames(), func(index int) [][]string {
+		return stream.firstRows(index, headerScanLength(input))
+	})
+	if err == nil {
+		err = stream.openSheet(sheetIndex)
+	}
+	if err != nil {
+		stream.close()
+		return nil, err
+	}
+
+	// Rows up to and including the header row are consumed, the first row is always
+	// consumed to match the non-streaming parser
+	firstRows, err := stream.readRows(headerScanLength(input))
+	if err != nil && err != io.EOF {
+		stream.close()
+		return nil, ErrUnableToParse{input.Path}
+	}
+
+	firstRowTexts := [][]string{}
+	for _, row := range firstRows {
+		firstRowTexts = append(firstRowTexts, cellTexts(row))
+	}
+
+	var headers []string
+	headerRow := 0
+	if input.HasHeaders {
+		headerRow = findHeaderRow(input, firstRowTexts)
+		headers = headerRowValues(input, firstRowTexts)
+	}
+
+	parser := &XlsxParser{
+		path:       input.Path,
+		stream:     stream,
+		sheetName:  stream.sheets[sheetIndex].name,
 		hasHeaders: input.HasHeaders,
 		headers:    headers,
+		aliases:    input.HeaderAliases,
+		index:      newHeaderIndex(headers, input.HeaderAliases),
+		report:     input.Report,
+	}
+
+	for i := headerRow + 1; i < len(firstRows); i++ {
+		parser.buffered = append(parser.buffered, XlsxRow{p: parser, cells: firstRows[i], index: i})
 	}
 
 	err = AssertHeadersExist(parser, input.RequiredHeaders)
@@ -59,6 +157,21 @@ func NewXlsxParser(input ParserInput) (*XlsxParser, error) {
 
 // Next returns the next Row
 func (p *XlsxParser) Next() (Row, error) {
+	if p.stream != nil {
+		if len(p.buffered) > 0 {
+			row := p.buffered[0]
+			p.buffered = p.buffered[1:]
+			return row, nil
+		}
+
+		cells, err := p.stream.next()
+		if err != nil {
+			return XlsxRow{}, err
+		}
+
+		return XlsxRow{p: p, cells: cells, index: p.stream.rowNum - 1}, nil
+	}
+
 	nextRow := p.currentRow + 1
 	if nextRow > p.numRows {
 		return XlsxRow{}, ErrEOF
@@ -67,18 +180,22 @@ func (p *XlsxParser) Next() (Row, error) {
 	p.currentRow = nextRow
 	row := p.sheet.Row(nextRow)
 
-	return XlsxRow{p: p, row: row}, nil
+	return XlsxRow{p: p, row: row, index: nextRow}, nil
 }
 
-// Close is unimplemented and unnecessary for xlsx files
+// Close closes the underlying file when streaming, otherwise it's
+// handled automatically by the xlsx library
 func (p XlsxParser) Close() {
-	// Handled automatically by xlsx library
+	if p.stream != nil {
+		p.stream.close()
+	}
 }
 
 // SetHeaderNames sets header names, allowing retrieval of columns by name
 func (p *XlsxParser) SetHeaderNames(names []string) {
 	p.headers = names
 	p.hasHeaders = true
+	p.index = newHeaderIndex(names, p.aliases)
 }
 
 // Headers returns the headers found or set on the current parsed file
@@ -86,6 +203,10 @@ func (p XlsxParser) Headers() []string {
 	return p.headers
 }
 
+func (p XlsxParser) headerIndex() headerIndex {
+	return p.index
+}
+
 // Path returns the path used for the file being parsed
 func (p XlsxParser) Path() string {
 	return p.path
@@ -93,6 +214,14 @@ func (p XlsxParser) Path() string {
 
 // Col returns the string in the specified column
 func (r XlsxRow) Col(index int) string {
+	if r.row == nil {
+		if index < 0 || index > len(r.cells)-1 {
+			return ""
+		}
+
+		return r.cells[index].Text
+	}
+
 	if index < 0 || index > len(r.row.Cells)-1 {
 		return ""
 	}
@@ -105,7 +234,54 @@ func (r XlsxRow) Col(index int) string {
 	return cell.String()
 }
 
+// Cell returns the typed value in the specified column
+func (r XlsxRow) Cell(index int) Cell {
+	if r.row == nil {
+		if index < 0 || index > len(r.cells)-1 {
+			return Cell{Type: CellEmpty}
+		}
+
+		return r.cells[index]
+	}
+
+	if index < 0 || index > len(r.row.Cells)-1 || r.row.Cells[index] == nil {
+		return Cell{Type: CellEmpty}
+	}
+
+	return xlsxCell(r.row.Cells[index], r.p.date1904)
+}
+
 // Headers returns the headers from the XLSX
 func (r XlsxRow) Headers() []string {
 	return r.p.headers
 }
+
+// Location returns the file, sheet and row number the row was read from
+func (r XlsxRow) Location() Location {
+	return Location{Path: r.p.path, Sheet: r.p.sheetName, Row: r.index + 1}
+}
+
+func (r XlsxRow) headerIndex() headerIndex {
+	return r.p.index
+}
+
+func (r XlsxRow) report() *Report {
+	return r.p.report
+}
+
+func xlsxRowValues(row *xlsx.Row) []string {
+	values := []string{}
+	for _, cell := range row.Cells {
+		values = append(values, cell.String())
+	}
+	return values
+}
+
+// xlsxFirstRows returns the cells for up to n rows from the top of the sheet
+func xlsxFirstRows(sheet *xlsx.Sheet, n int) [][]string {
+	rows := [][]string{}
+	for i := 0; i < n && i < sheet.MaxRow; i++ {
+		rows = append(rows, xlsxRowValues(sheet.Row(i)))
+	}
+	return rows
+}
@@ -19,3 +19,59 @@ func TestXlsxNext(t *testing.T) {
 		AssertColumnNamed(t, row, "CLAIMREFERENCE", "000017")
 	})
 }
+
+func TestXlsxStreamNext(t *testing.T) {
+	input := ParserInput{Path: "./testdata/Consent Report W360.xlsx", HasHeaders: true, Stream: true}
+
+	t.Run("Allows retrieval of column data by name", func(t *testing.T) {
+		parser, err := NewXlsxParser(input)
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+		defer parser.Close()
+
+		row, err := parser.Next()
+		if err != nil {
+			t.Fatalf("Got an unexpected error %#v", err)
+		}
+
+		AssertColumnNamed(t, row, "DocDesc", "FSM Application")
+		AssertColumnNamed(t, row, "DocDate", "12/12/18 1:23:45")
+		AssertColumnNamed(t, row, "CLAIMREFERENCE", "000017")
+	})
+
+	t.Run("Returns the same values as loading the whole workbook", func(t *testing.T) {
+		streamed, err := NewXlsxParser(input)
+		if err != nil {
+			t.Fatalf("Error creating streaming parser")
+		}
+		defer streamed.Close()
+
+		loaded, err := NewXlsxParser(ParserInput{Path: input.Path, HasHeaders: true})
+		if err != nil {
+			t.Fatalf("Error creating parser")
+		}
+
+		for {
+			streamedRow, err := streamed.Next()
+			if err == ErrEOF {
+				break
+			} else if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			loadedRow, err := loaded.Next()
+			if err != nil {
+				t.Fatalf("Got an unexpected error %#v", err)
+			}
+
+			for _, header := range loaded.Headers() {
+				AssertColumnNamed(t, streamedRow, header, ColByName(loadedRow, header))
+			}
+
+			if streamedRow.Location() != loadedRow.Location() {
+				t.Errorf("Expected location %s but got %s", loadedRow.Location(), streamedRow.Location())
+			}
+		}
+	})
+}
@@ -0,0 +1,452 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// errMissingXlsxPart is returned when part of the xlsx zip we rely on is missing
+var errMissingXlsxPart = errors.New("Missing part of xlsx file")
+
+// builtInNumFmts are the number formats Excel doesn't store in styles.xml
+var builtInNumFmts = map[int]string{
+	0:  "general",
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	9:  "0%",
+	10: "0.00%",
+	11: "0.00e+00",
+	12: "# ?/?",
+	13: "# ??/??",
+	14: "mm-dd-yy",
+	15: "d-mmm-yy",
+	16: "d-mmm",
+	17: "mmm-yy",
+	18: "h:mm am/pm",
+	19: "h:mm:ss am/pm",
+	20: "h:mm",
+	21: "h:mm:ss",
+	22: "m/d/yy h:mm",
+	37: "#,##0 ;(#,##0)",
+	38: "#,##0 ;[red](#,##0)",
+	39: "#,##0.00;(#,##0.00)",
+	40: "#,##0.00;[red](#,##0.00)",
+	45: "mm:ss",
+	46: "[h]:mm:ss",
+	47: "mmss.0",
+	48: "##0.0e+0",
+	49: "@",
+}
+
+// xlsxStream reads rows directly from the sheet xml inside an xlsx file.
+// Only the shared strings and styles are held in memory, rows are decoded one at a time.
+type xlsxStream struct {
+	zip           *zip.Reader
+	closer        io.Closer
+	files         map[string]*zip.File
+	sheets        []xlsxSheetRef
+	sheetFile     io.ReadCloser
+	decoder       *xml.Decoder
+	sharedStrings []string
+	numFmts       []string // number format for each cell style index
+	date1904      bool
+	pending       *xlsxStreamRow
+	rowNum        int
+}
+
+// xlsxSheetRef is the name of a sheet and the path of its xml within the zip
+type xlsxSheetRef struct {
+	name string
+	path string
+}
+
+type xlsxStreamRow struct {
+	R     int              `xml:"r,attr"`
+	Cells []xlsxStreamCell `xml:"c"`
+}
+
+type xlsxStreamCell struct {
+	R  string         `xml:"r,attr"`
+	T  string         `xml:"t,attr"`
+	S  int            `xml:"s,attr"`
+	F  *struct{}      `xml:"f"`
+	V  string         `xml:"v"`
+	Is xlsxStreamText `xml:"is"`
+}
+
+// xlsxStreamText is either plain text or a list of rich text runs
+type xlsxStreamText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxStreamText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+// openXlsxStream opens the xlsx input, loading the workbook level data needed to stream
+// its sheets. openSheet must be called before reading rows.
+func openXlsxStream(input ParserInput) (*xlsxStream, error) {
+	reader, closer, err := openSizedInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	zipReader, err := zip.NewReader(reader, reader.Size())
+	if err != nil {
+		closer.Close()
+		return nil, ErrUnableToParse{input.Path}
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range zipReader.File {
+		files[f.Name] = f
+	}
+
+	stream := &xlsxStream{zip: zipReader, closer: closer, files: files}
+
+	stream.sheets, stream.date1904, err = readWorkbook(files)
+	if err == nil {
+		stream.sharedStrings, err = readSharedStrings(files["xl/sharedStrings.xml"])
+	}
+	if err == nil {
+		stream.numFmts, err = readCellNumFmts(files["xl/styles.xml"])
+	}
+	if err != nil {
+		closer.Close()
+		return nil, ErrUnableToParse{input.Path}
+	}
+
+	return stream, nil
+}
+
+// sheetNames returns the names of all sheets in the workbook
+func (s *xlsxStream) sheetNames() []string {
+	names := []string{}
+	for _, sheet := range s.sheets {
+		names = append(names, sheet.name)
+	}
+	return names
+}
+
+// openSheet prepares the sheet at the given index for reading from the first row.
+// Any previously opened sheet is closed.
+func (s *xlsxStream) openSheet(index int) error {
+	if s.sheetFile != nil {
+		s.sheetFile.Close()
+		s.sheetFile = nil
+	}
+
+	if index < 0 || index >= len(s.sheets) || s.files[s.sheets[index].path] == nil {
+		return errMissingXlsxPart
+	}
+
+	sheetFile, err := s.files[s.sheets[index].path].Open()
+	if err != nil {
+		return err
+	}
+
+	s.sheetFile = sheetFile
+	s.decoder = xml.NewDecoder(sheetFile)
+	s.pending = nil
+	s.rowNum = 0
+	return nil
+}
+
+// firstRows returns up to n rows from the top of the sheet at the given index
+func (s *xlsxStream) firstRows(index int, n int) [][]string {
+	if err := s.openSheet(index); err != nil {
+		return [][]string{}
+	}
+
+	rows, _ := s.readRows(n)
+
+	texts := [][]string{}
+	for _, row := range rows {
+		texts = append(texts, cellTexts(row))
+	}
+	return texts
+}
+
+// readRows reads up to n rows from the open sheet, stopping early at the end of the sheet
+func (s *xlsxStream) readRows(n int) ([][]Cell, error) {
+	rows := [][]Cell{}
+	for len(rows) < n {
+		row, err := s.next()
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// next returns the cells in the next row of the sheet. Rows missing from the
+// sheet xml (i.e. blank rows) are returned as empty rows.
+func (s *xlsxStream) next() ([]Cell, error) {
+	if s.pending == nil {
+		row, err := s.readRow()
+		if err != nil {
+			return nil, err
+		}
+		s.pending = row
+	}
+
+	s.rowNum++
+	if s.pending.R > s.rowNum {
+		return []Cell{}, nil
+	}
+
+	row := s.pending
+	s.pending = nil
+	return s.cellValues(row), nil
+}
+
+func (s *xlsxStream) readRow() (*xlsxStreamRow, error) {
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		row := &xlsxStreamRow{}
+		err = s.decoder.DecodeElement(row, &start)
+		return row, err
+	}
+}
+
+func (s *xlsxStream) cellValues(row *xlsxStreamRow) []Cell {
+	values := []Cell{}
+
+	for i, cell := range row.Cells {
+		col := i
+		if cell.R != "" {
+			if x, _, err := xlsx.GetCoordsFromCellIDString(cell.R); err == nil {
+				col = x
+			}
+		}
+
+		for len(values) <= col {
+			values = append(values, Cell{Type: CellEmpty})
+		}
+		values[col] = s.cellValue(cell)
+	}
+
+	return values
+}
+
+func (s *xlsxStream) cellValue(cell xlsxStreamCell) Cell {
+	value := Cell{Type: CellString, Formula: cell.F != nil}
+
+	switch cell.T {
+	case "s":
+		index, err := strconv.Atoi(cell.V)
+		if err != nil || index < 0 || index >= len(s.sharedStrings) {
+			value.Type = CellEmpty
+			return value
+		}
+		value.Text = s.sharedStrings[index]
+	case "inlineStr":
+		value.Text = cell.Is.String()
+	case "str":
+		value.Text = cell.V
+	case "b":
+		value.Type = CellBool
+		value.Text = cell.V
+		if cell.V == "1" {
+			value.Number = 1
+		}
+	case "e":
+		value.Type = CellError
+		value.Text = cell.V
+	default:
+		return s.numericCellValue(cell, value)
+	}
+
+	if value.Text == "" {
+		value.Type = CellEmpty
+	}
+
+	return value
+}
+
+func (s *xlsxStream) numericCellValue(cell xlsxStreamCell, value Cell) Cell {
+	value.Text = cell.V
+	if cell.V == "" {
+		value.Type = CellEmpty
+		return value
+	}
+
+	number, err := strconv.ParseFloat(cell.V, 64)
+	if err != nil {
+		return value
+	}
+
+	format := builtInNumFmts[0]
+	if cell.S >= 0 && cell.S < len(s.numFmts) {
+		format = s.numFmts[cell.S]
+	}
+
+	// Format the same way the xlsx library does when loading the whole file
+	formatted := &xlsx.Cell{}
+	formatted.SetFloatWithFormat(number, format)
+
+	value.Type = CellNumber
+	value.Text = formatted.String()
+	value.Number = number
+	if formatted.IsTime() {
+		value.Type = CellDate
+		value.Date = xlsx.TimeFromExcelTime(number, s.date1904)
+	}
+
+	return value
+}
+
+func (s *xlsxStream) close() error {
+	if s.sheetFile != nil {
+		s.sheetFile.Close()
+	}
+	return s.closer.Close()
+}
+
+// readWorkbook finds the name and xml path of each sheet in the workbook, and if it uses the 1904 date system
+func readWorkbook(files map[string]*zip.File) ([]xlsxSheetRef, bool, error) {
+	var workbook struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name  string `xml:"name,attr"`
+			RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files["xl/workbook.xml"], &workbook); err != nil {
+		return nil, false, err
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return nil, false, err
+	}
+
+	targets := make(map[string]string)
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	sheets := []xlsxSheetRef{}
+	for _, sheet := range workbook.Sheets {
+		sheets = append(sheets, xlsxSheetRef{name: sheet.Name, path: targets[sheet.RelID]})
+	}
+
+	date1904 := workbook.Properties.Date1904 == "1" || workbook.Properties.Date1904 == "true"
+	return sheets, date1904, nil
+}
+
+// readSharedStrings loads the shared string table. Not all files have one.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	var sst struct {
+		Items []xlsxStreamText `xml:"si"`
+	}
+	if err := decodeZipXML(f, &sst); err != nil {
+		return nil, err
+	}
+
+	sharedStrings := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		sharedStrings[i] = item.String()
+	}
+
+	return sharedStrings, nil
+}
+
+// readCellNumFmts returns the number format code used by each cell style
+func readCellNumFmts(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := decodeZipXML(f, &styles); err != nil {
+		return nil, err
+	}
+
+	customFmts := make(map[int]string)
+	for _, numFmt := range styles.NumFmts {
+		customFmts[numFmt.ID] = numFmt.Code
+	}
+
+	// Resolved the same way as the xlsx library: built in formats take priority and
+	// custom formats are only used for ids outside the reserved built in range
+	numFmts := make([]string, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		numFmts[i] = builtInNumFmts[0]
+		if code, ok := builtInNumFmts[xf.NumFmtID]; ok {
+			numFmts[i] = code
+		} else if code, ok := customFmts[xf.NumFmtID]; ok && xf.NumFmtID > 163 {
+			numFmts[i] = code
+		}
+	}
+
+	return numFmts, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f == nil {
+		return errMissingXlsxPart
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return xml.NewDecoder(r).Decode(v)
+}
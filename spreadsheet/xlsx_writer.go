@@ -0,0 +1,96 @@
+package spreadsheet
+
+import (
+	"time"
+
+	"github.com/tealeg/xlsx"
+)
+
+// xlsxDateFormat is the number format of date cells written to xlsx files
+const xlsxDateFormat = "dd/mm/yyyy"
+
+// XlsxWriter writes sheets to a single xlsx workbook. Header rows are frozen and have an autofilter,
+// and cells keep their types so text identifiers aren't converted to numbers when the file is opened.
+type XlsxWriter struct {
+	path    string
+	file    *xlsx.File
+	sheet   *xlsx.Sheet
+	columns int
+}
+
+// NewXlsxWriter creates an XlsxWriter that saves the workbook to the path when closed
+func NewXlsxWriter(path string) *XlsxWriter {
+	return &XlsxWriter{path: path, file: xlsx.NewFile()}
+}
+
+// AddSheet adds a sheet to the workbook with a frozen header row
+func (w *XlsxWriter) AddSheet(name string, headers []string) error {
+	w.finishSheet()
+
+	sheet, err := w.file.AddSheet(name)
+	if err != nil {
+		return err
+	}
+
+	sheet.SheetViews = []xlsx.SheetView{{
+		Pane: &xlsx.Pane{YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft", State: "frozen"},
+	}}
+
+	row := sheet.AddRow()
+	for _, header := range headers {
+		row.AddCell().SetString(header)
+	}
+
+	w.sheet = sheet
+	w.columns = len(headers)
+	return nil
+}
+
+// Write adds a row to the current sheet
+func (w *XlsxWriter) Write(cells []Cell) error {
+	if w.sheet == nil {
+		return ErrNoSheet{}
+	}
+
+	row := w.sheet.AddRow()
+	for _, cell := range cells {
+		setXlsxCell(row.AddCell(), cell)
+	}
+
+	if len(cells) > w.columns {
+		w.columns = len(cells)
+	}
+	return nil
+}
+
+// Close saves the workbook
+func (w *XlsxWriter) Close() error {
+	w.finishSheet()
+	return w.file.Save(w.path)
+}
+
+// finishSheet adds an autofilter covering the rows of the current sheet
+func (w *XlsxWriter) finishSheet() {
+	if w.sheet != nil && w.columns > 0 {
+		w.sheet.AutoFilter = &xlsx.AutoFilter{
+			TopLeftCell:     "A1",
+			BottomRightCell: xlsx.GetCellIDStringFromCoords(w.columns-1, len(w.sheet.Rows)-1),
+		}
+	}
+	w.sheet = nil
+}
+
+// setXlsxCell sets the value of a cell in an xlsx file. Formulas are written as their value.
+func setXlsxCell(c *xlsx.Cell, cell Cell) {
+	switch cell.Type {
+	case CellNumber:
+		c.SetFloat(cell.Number)
+	case CellDate:
+		c.SetDateWithOptions(cell.Date, xlsx.DateTimeOptions{Location: time.UTC, ExcelTimeFormat: xlsxDateFormat})
+	case CellBool:
+		c.SetBool(cell.Number != 0)
+	case CellEmpty:
+	default:
+		c.SetString(cell.Text)
+	}
+}